@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	jobRepo "scrapper/domain/repository/job"
+	"scrapper/domain/service/image"
+	"scrapper/domain/service/job"
+	logger "scrapper/infrastructure/log"
+	"scrapper/infrastructure/storage"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultListLimit = 100
+	maxRequestBody   = 1 << 20
+)
+
+// StartJobFunc starts a create job in the background and returns once the job
+// is stored, the job keeps running after the request is answered. Errors
+// caused by the request wrap job.ErrInvalidRequest
+type StartJobFunc func(ctx context.Context, req job.Request) (*entity.Job, error)
+
+// Server exposes the jobs and the stored images over HTTP
+type Server struct {
+	logger       logger.Logger
+	imageService *image.Service
+	jobService   *job.Service
+	startJob     StartJobFunc
+}
+
+func NewServer(logger logger.Logger, imageService *image.Service, jobService *job.Service, startJob StartJobFunc) *Server {
+	return &Server{
+		logger:       logger,
+		imageService: imageService,
+		jobService:   jobService,
+		startJob:     startJob,
+	}
+}
+
+// Handler returns the routes of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.createJob)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /images", s.listImages)
+	mux.HandleFunc("GET /images/{id}/file", s.getImageFile)
+	return mux
+}
+
+type jobResponse struct {
+	ID          int64             `json:"id"`
+	TargetCount uint64            `json:"target_count"`
+	SavedCount  uint64            `json:"saved_count"`
+	Status      string            `json:"status"`
+	Queries     []entity.JobQuery `json:"queries"`
+	Engines     []string          `json:"engines"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+func toJobResponse(j *entity.Job) jobResponse {
+	return jobResponse{
+		ID:          j.ID,
+		TargetCount: j.TargetCount,
+		SavedCount:  j.SavedCount,
+		Status:      j.Status,
+		Queries:     j.Queries,
+		Engines:     j.Engines,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+}
+
+type variantResponse struct {
+	Name        string `json:"name"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ByteSize    int64  `json:"byte_size"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+}
+
+type imageResponse struct {
+	ID             int64             `json:"id"`
+	FileURL        string            `json:"file_url"`
+	SourceURL      string            `json:"source_url"`
+	SearchEngine   string            `json:"search_engine"`
+	Query          string            `json:"query"`
+	OriginalWidth  int               `json:"original_width"`
+	OriginalHeight int               `json:"original_height"`
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+	ByteSize       int64             `json:"byte_size"`
+	ContentType    string            `json:"content_type"`
+	SHA256         string            `json:"sha256"`
+	JobID          int64             `json:"job_id,omitempty"`
+	Variants       []variantResponse `json:"variants"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+func toImageResponse(img *entity.Image) imageResponse {
+	variants := make([]variantResponse, 0, len(img.Variants))
+	for _, v := range img.Variants {
+		variants = append(variants, variantResponse{
+			Name:        v.Name,
+			Width:       v.Width,
+			Height:      v.Height,
+			ByteSize:    v.ByteSize,
+			ContentType: v.ContentType,
+			SHA256:      v.SHA256,
+		})
+	}
+	return imageResponse{
+		ID:             img.ID,
+		FileURL:        fmt.Sprintf("/images/%d/file", img.ID),
+		SourceURL:      img.SourceURL,
+		SearchEngine:   img.SearchEngine,
+		Query:          img.Query,
+		OriginalWidth:  img.OriginalWidth,
+		OriginalHeight: img.OriginalHeight,
+		Width:          img.Width,
+		Height:         img.Height,
+		ByteSize:       img.ByteSize,
+		ContentType:    img.ContentType,
+		SHA256:         img.SHA256,
+		JobID:          img.JobID,
+		Variants:       variants,
+		CreatedAt:      img.CreatedAt,
+	}
+}
+
+// listResponse is a page of images, NextAfter is the after parameter of the
+// next page and is omitted on the last page
+type listResponse struct {
+	Images    []imageResponse `json:"images"`
+	NextAfter int64           `json:"next_after,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	var req job.Request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("decoding body: %w", err))
+		return
+	}
+	if req.Count == 0 {
+		s.writeError(w, http.StatusBadRequest, errors.New("count must be greater than zero"))
+		return
+	}
+	j, err := s.startJob(r.Context(), req.WithDefaults())
+	if errors.Is(err, job.ErrInvalidRequest) {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		s.internalError(w, fmt.Errorf("starting job: %w", err))
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/jobs/%d", j.ID))
+	s.writeJSON(w, http.StatusAccepted, toJobResponse(j))
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pathID(w, r)
+	if !ok {
+		return
+	}
+	j, err := s.jobService.Progress(r.Context(), id)
+	if errors.Is(err, jobRepo.ErrNotFound) {
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("job %d not found", id))
+		return
+	}
+	if err != nil {
+		s.internalError(w, fmt.Errorf("getting job %d: %w", id, err))
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toJobResponse(j))
+}
+
+// listImages returns the images ordered by id, ?after=<id> starts the page
+// after that image and ?limit= sets its size
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var afterID int64
+	if after := query.Get("after"); after != "" {
+		var err error
+		afterID, err = strconv.ParseInt(after, 10, 64)
+		if err != nil || afterID < 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid after %q", after))
+			return
+		}
+	}
+	limit := defaultListLimit
+	if l := query.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 || limit > image.MaxListLimit {
+			s.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q, use 1 to %d", l, image.MaxListLimit))
+			return
+		}
+	}
+	images, err := s.imageService.List(r.Context(), afterID, limit)
+	if err != nil {
+		s.internalError(w, fmt.Errorf("listing images: %w", err))
+		return
+	}
+	resp := listResponse{Images: make([]imageResponse, 0, len(images))}
+	for _, img := range images {
+		resp.Images = append(resp.Images, toImageResponse(img))
+	}
+	if len(images) == limit {
+		resp.NextAfter = images[len(images)-1].ID
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) getImageFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pathID(w, r)
+	if !ok {
+		return
+	}
+	img, file, err := s.imageService.OpenFile(r.Context(), id)
+	switch {
+	case errors.Is(err, imageRepo.ErrNotFound):
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("image %d not found", id))
+		return
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, image.ErrOtherBackend):
+		s.logger.Warning(fmt.Sprintf("file of image %d: %s", id, err))
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("file of image %d not found", id))
+		return
+	case err != nil:
+		s.internalError(w, fmt.Errorf("opening file of image %d: %w", id, err))
+		return
+	}
+	defer file.Close()
+
+	//files are content addressed, a stored file never changes
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("ETag", strconv.Quote(img.SHA256))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", img.CreatedAt, rs)
+		return
+	}
+	if _, err := io.Copy(w, file); err != nil {
+		s.logger.Warning(fmt.Sprintf("sending file of image %d: %s", id, err))
+	}
+}
+
+func (s *Server) pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid id %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	s.logger.Error(err)
+	s.writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, err error) {
+	s.writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warning(fmt.Sprintf("writing response: %s", err))
+	}
+}
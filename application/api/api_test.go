@@ -0,0 +1,368 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"scrapper/application/apitest"
+	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	jobRepo "scrapper/domain/repository/job"
+	"scrapper/domain/service/image"
+	"scrapper/domain/service/job"
+	"scrapper/infrastructure/storage/filesystem"
+	mock_image "scrapper/mock/repository"
+	"strings"
+	"testing"
+)
+
+type testServer struct {
+	*apitest.Services
+	server *httptest.Server
+}
+
+func newTestServer(t *testing.T, startJob StartJobFunc) *testServer {
+	ts := &testServer{Services: apitest.NewServices(t)}
+	ts.server = httptest.NewServer(NewServer(ts.Logger, ts.ImageService, ts.JobService, startJob).Handler())
+	t.Cleanup(ts.server.Close)
+	return ts
+}
+
+func (ts *testServer) get(t *testing.T, path string) *http.Response {
+	resp, err := http.Get(ts.server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, v any) {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_CreateJob(t *testing.T) {
+	var tests = []struct {
+		name        string
+		body        string
+		startErr    error
+		mustStatus  int
+		mustQueries []entity.JobQuery
+	}{
+		{
+			name:        "Success",
+			body:        `{"count":10,"queries":[{"text":"cat","weight":2}],"engines":["bing"]}`,
+			mustStatus:  http.StatusAccepted,
+			mustQueries: []entity.JobQuery{{Text: "cat", Weight: 2}},
+		},
+		{
+			name:        "NoWeight",
+			body:        `{"count":10,"queries":[{"text":"cat"},{"text":"dog","weight":3}],"engines":["bing"]}`,
+			mustStatus:  http.StatusAccepted,
+			mustQueries: []entity.JobQuery{{Text: "cat", Weight: 1}, {Text: "dog", Weight: 3}},
+		},
+		{
+			name:       "ZeroCount",
+			body:       `{"count":0}`,
+			mustStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "UnknownField",
+			body:       `{"count":10,"cnt":10}`,
+			mustStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "InvalidJob",
+			body:       `{"count":10,"engines":["altavista"]}`,
+			startErr:   fmt.Errorf("%w: unknown engine", job.ErrInvalidRequest),
+			mustStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "StartError",
+			body:       `{"count":10}`,
+			startErr:   errors.New("connection refused"),
+			mustStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got job.Request
+			ts := newTestServer(t, func(ctx context.Context, req job.Request) (*entity.Job, error) {
+				got = req
+				if test.startErr != nil {
+					return nil, test.startErr
+				}
+				return &entity.Job{ID: 7, TargetCount: req.Count, Queries: req.Queries, Status: entity.JobStatusRunning}, nil
+			})
+			resp, err := http.Post(ts.server.URL+"/jobs", "application/json", strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != test.mustStatus {
+				t.Fatalf("status is %d, must be %d", resp.StatusCode, test.mustStatus)
+			}
+			if test.mustStatus != http.StatusAccepted {
+				return
+			}
+			if location := resp.Header.Get("Location"); location != "/jobs/7" {
+				t.Errorf("location is %q, must be /jobs/7", location)
+			}
+			var j jobResponse
+			decode(t, resp, &j)
+			if j.ID != 7 || j.TargetCount != 10 || j.Status != entity.JobStatusRunning {
+				t.Errorf("job is %+v", j)
+			}
+			if fmt.Sprint(got.Queries) != fmt.Sprint(test.mustQueries) || len(got.Engines) != 1 || got.Engines[0] != "bing" {
+				t.Errorf("job request is %+v, must have queries %v", got, test.mustQueries)
+			}
+		})
+	}
+}
+
+func TestServer_GetJob(t *testing.T) {
+	var tests = []struct {
+		name       string
+		path       string
+		mock       func(*mock_image.MockJob)
+		mustStatus int
+		mustSaved  uint64
+	}{
+		{
+			name: "Running",
+			path: "/jobs/3",
+			mock: func(m *mock_image.MockJob) {
+				m.EXPECT().Get(gomock.Any(), int64(3)).
+					Return(&entity.Job{ID: 3, TargetCount: 100, Status: entity.JobStatusRunning}, nil)
+				m.EXPECT().CountImages(gomock.Any(), int64(3)).Return(uint64(42), nil)
+			},
+			mustStatus: http.StatusOK,
+			mustSaved:  42,
+		},
+		{
+			name: "Completed",
+			path: "/jobs/3",
+			mock: func(m *mock_image.MockJob) {
+				m.EXPECT().Get(gomock.Any(), int64(3)).
+					Return(&entity.Job{ID: 3, TargetCount: 100, SavedCount: 100, Status: entity.JobStatusCompleted}, nil)
+			},
+			mustStatus: http.StatusOK,
+			mustSaved:  100,
+		},
+		{
+			name: "NotFound",
+			path: "/jobs/4",
+			mock: func(m *mock_image.MockJob) {
+				m.EXPECT().Get(gomock.Any(), int64(4)).Return(nil, jobRepo.ErrNotFound)
+			},
+			mustStatus: http.StatusNotFound,
+		},
+		{
+			name:       "InvalidID",
+			path:       "/jobs/abc",
+			mock:       func(m *mock_image.MockJob) {},
+			mustStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTestServer(t, nil)
+			test.mock(ts.JobRepoMock)
+			resp := ts.get(t, test.path)
+			if resp.StatusCode != test.mustStatus {
+				t.Fatalf("status is %d, must be %d", resp.StatusCode, test.mustStatus)
+			}
+			if test.mustStatus != http.StatusOK {
+				return
+			}
+			var j jobResponse
+			decode(t, resp, &j)
+			if j.SavedCount != test.mustSaved {
+				t.Errorf("saved count is %d, must be %d", j.SavedCount, test.mustSaved)
+			}
+		})
+	}
+}
+
+func TestServer_ListImages(t *testing.T) {
+	images := func(ids ...int64) []*entity.Image {
+		result := make([]*entity.Image, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, &entity.Image{ID: id, File: fmt.Sprintf("%d.jpg", id)})
+		}
+		return result
+	}
+
+	var tests = []struct {
+		name          string
+		query         string
+		mock          func(*mock_image.MockImage)
+		mustStatus    int
+		mustIDs       []int64
+		mustNextAfter int64
+	}{
+		{
+			name:  "FirstPage",
+			query: "?limit=2",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().List(gomock.Any(), int64(0), 2).Return(images(1, 2), nil)
+			},
+			mustStatus:    http.StatusOK,
+			mustIDs:       []int64{1, 2},
+			mustNextAfter: 2,
+		},
+		{
+			name:  "LastPage",
+			query: "?after=2&limit=2",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().List(gomock.Any(), int64(2), 2).Return(images(5), nil)
+			},
+			mustStatus: http.StatusOK,
+			mustIDs:    []int64{5},
+		},
+		{
+			name:  "DefaultLimit",
+			query: "",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().List(gomock.Any(), int64(0), defaultListLimit).Return(images(), nil)
+			},
+			mustStatus: http.StatusOK,
+			mustIDs:    []int64{},
+		},
+		{
+			name:       "LimitTooLarge",
+			query:      fmt.Sprintf("?limit=%d", image.MaxListLimit+1),
+			mock:       func(m *mock_image.MockImage) {},
+			mustStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "InvalidAfter",
+			query:      "?after=-1",
+			mock:       func(m *mock_image.MockImage) {},
+			mustStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "RepoError",
+			query: "",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().List(gomock.Any(), int64(0), defaultListLimit).Return(nil, errors.New("connection refused"))
+			},
+			mustStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTestServer(t, nil)
+			test.mock(ts.ImageRepoMock)
+			resp := ts.get(t, "/images"+test.query)
+			if resp.StatusCode != test.mustStatus {
+				t.Fatalf("status is %d, must be %d", resp.StatusCode, test.mustStatus)
+			}
+			if test.mustStatus != http.StatusOK {
+				return
+			}
+			var page listResponse
+			decode(t, resp, &page)
+			if len(page.Images) != len(test.mustIDs) {
+				t.Fatalf("got %d images, must get %d", len(page.Images), len(test.mustIDs))
+			}
+			for i, img := range page.Images {
+				if img.ID != test.mustIDs[i] {
+					t.Errorf("image %d has id %d, must have %d", i, img.ID, test.mustIDs[i])
+				}
+				if mustURL := fmt.Sprintf("/images/%d/file", img.ID); img.FileURL != mustURL {
+					t.Errorf("file url is %q, must be %q", img.FileURL, mustURL)
+				}
+			}
+			if page.NextAfter != test.mustNextAfter {
+				t.Errorf("next after is %d, must be %d", page.NextAfter, test.mustNextAfter)
+			}
+		})
+	}
+}
+
+func TestServer_GetImageFile(t *testing.T) {
+	content := []byte("jpeg bytes")
+	stored := &entity.Image{ID: 1, File: "ab/cd/abcd.jpg", StorageBackend: filesystem.Name, ContentType: "image/jpeg", SHA256: "abcd"}
+
+	var tests = []struct {
+		name       string
+		mock       func(*mock_image.MockImage)
+		mustStatus int
+	}{
+		{
+			name: "Success",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().Get(gomock.Any(), int64(1)).Return(stored, nil)
+			},
+			mustStatus: http.StatusOK,
+		},
+		{
+			name: "UnknownImage",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().Get(gomock.Any(), int64(1)).Return(nil, imageRepo.ErrNotFound)
+			},
+			mustStatus: http.StatusNotFound,
+		},
+		{
+			name: "MissingFile",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().Get(gomock.Any(), int64(1)).
+					Return(&entity.Image{ID: 1, File: "missing.jpg", StorageBackend: filesystem.Name}, nil)
+			},
+			mustStatus: http.StatusNotFound,
+		},
+		{
+			name: "OtherBackend",
+			mock: func(m *mock_image.MockImage) {
+				m.EXPECT().Get(gomock.Any(), int64(1)).
+					Return(&entity.Image{ID: 1, File: stored.File, StorageBackend: "s3"}, nil)
+			},
+			mustStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTestServer(t, nil)
+			if err := os.MkdirAll(filepath.Join(ts.Root, "ab", "cd"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(ts.Root, "ab", "cd", "abcd.jpg"), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			test.mock(ts.ImageRepoMock)
+			resp := ts.get(t, "/images/1/file")
+			if resp.StatusCode != test.mustStatus {
+				t.Fatalf("status is %d, must be %d", resp.StatusCode, test.mustStatus)
+			}
+			if test.mustStatus != http.StatusOK {
+				return
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "image/jpeg" {
+				t.Errorf("content type is %q, must be image/jpeg", contentType)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != string(content) {
+				t.Errorf("body is %q, must be %q", body, content)
+			}
+		})
+	}
+}
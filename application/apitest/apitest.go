@@ -0,0 +1,53 @@
+// Package apitest builds the services behind the HTTP and gRPC APIs for their
+// tests, each API package only adds its own transport on top
+package apitest
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"scrapper/domain/service/image"
+	"scrapper/domain/service/job"
+	"scrapper/infrastructure/storage/filesystem"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"testing"
+)
+
+// Services are the services of the APIs on top of mock repositories and a
+// filesystem storage in a temp dir
+type Services struct {
+	Logger        *mock_log.MockLog
+	ImageRepoMock *mock_image.MockImage
+	JobRepoMock   *mock_image.MockJob
+	Root          string //root dir of the storage
+	ImageService  *image.Service
+	JobService    *job.Service
+}
+
+// NewServices returns the services, they are closed when the test ends. The
+// logger accepts any error and warning
+func NewServices(t *testing.T) *Services {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	s := &Services{
+		Logger:        mock_log.NewMockLog(ctrl),
+		ImageRepoMock: mock_image.NewMockImage(ctrl),
+		JobRepoMock:   mock_image.NewMockJob(ctrl),
+		Root:          t.TempDir(),
+	}
+	s.Logger.EXPECT().Error(gomock.Any()).AnyTimes()
+	s.Logger.EXPECT().Warning(gomock.Any()).AnyTimes()
+	st, err := filesystem.NewStorage(s.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ImageService = image.NewService(s.Logger, s.ImageRepoMock, st, image.WriterConfig{})
+	t.Cleanup(func() {
+		s.ImageService.Close(context.Background())
+	})
+	s.JobService = job.NewService(s.Logger, s.JobRepoMock)
+	return s
+}
@@ -1,133 +1,262 @@
 package command
 
 import (
-	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"scrapper/domain/entity"
 	userPgx "scrapper/domain/repository/image/pgx"
+	jobPgx "scrapper/domain/repository/job/pgx"
 	"scrapper/domain/service/image"
+	"scrapper/domain/service/job"
 	"scrapper/infrastructure/godotenv"
 	logger "scrapper/infrastructure/log"
 	"scrapper/infrastructure/log/zerolog"
 	pgxInfra "scrapper/infrastructure/pgx"
-	imgDown "scrapper/utils/image"
-	"strconv"
+	"scrapper/infrastructure/storage"
+	"scrapper/infrastructure/storage/filesystem"
+	"scrapper/infrastructure/storage/s3"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitInterrupted = 130
+
+	shutdownTimeout = 30 * time.Second
+	// staleTempAge is the age after which temp files of the storage are
+	// considered left behind by a crashed run
+	staleTempAge = 10 * time.Minute
+)
+
+var (
+	errUsage       = errors.New("invalid usage")
+	errInterrupted = errors.New("interrupted")
 )
 
-func Boot() {
-	logger := zerolog.NewLogger()
+type command struct {
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"create":            {"download and store images once, then exit", runCreate},
+	"read":              {"read images from the database once, then exit", runRead},
+	"resume":            {"continue an interrupted create job, 'sco resume [flags] <job-id>'", runResume},
+	"migrate-layout":    {"move stored files to the content addressed layout and update their rows", runMigrateLayout},
+	"replay-deadletter": {"store the images of batches that failed to be saved again", runReplayDeadLetter},
+	"fsck":              {"check that the image rows and the stored files match, --repair fixes them", runFsck},
+	"serve":             {"serve the HTTP API on ServerAddr until interrupted", runServe},
+	"interactive":       {"prompt for the method and count on stdin in a loop", runInteractive},
+}
+
+// app holds the dependencies shared by every subcommand
+type app struct {
+	logger       logger.Logger
+	env          *godotenv.Env
+	pool         *pgxpool.Pool
+	imageService *image.Service
+	jobService   *job.Service
+	storage      storage.Storage
+	storageDir   string
+}
+
+func newApp() (*app, error) {
+	lg := zerolog.NewLogger()
 	env := godotenv.NewEnv()
 	env.Load()
 
-	conn, err := pgxInfra.SetupPool(env.DATABASE_HOST)
+	pool, err := pgxInfra.SetupPool(env.DATABASE_HOST)
 	if err != nil {
-		logger.Error(err)
-		panic(err)
+		return nil, err
 	}
-	sd, err := os.Getwd()
+	wd, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		pool.Close()
+		return nil, err
+	}
+	sd := env.STORAGE_DIR
+	if sd == "" {
+		sd = filepath.Join(wd, "images")
+	}
+	st, err := newStorage(env, sd)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("setting up %s storage: %w", env.STORAGE_BACKEND, err)
 	}
-	sd = filepath.Join(sd, "images")
 
-	imageRepo := userPgx.NewImageRepository(conn)
-	addrService := image.NewService(logger, imageRepo, sd)
+	imageRepo := userPgx.NewImageRepository(pool)
+	jobRepo := jobPgx.NewJobRepository(pool)
+	return &app{
+		logger:       lg,
+		env:          env,
+		pool:         pool,
+		imageService: image.NewService(lg, imageRepo, st, writerConfig(env, pool, wd)),
+		jobService:   job.NewService(lg, jobRepo),
+		storage:      st,
+		storageDir:   sd,
+	}, nil
+}
 
-	method, proxy, err := getMethodAndProxyFromStdin()
-	if err != nil {
-		logger.Error(err)
-		panic(err)
+// writerConfig reads the batch writer settings, by default half of the pool
+// connections may write batches at the same time
+func writerConfig(env *godotenv.Env, pool *pgxpool.Pool, wd string) image.WriterConfig {
+	cfg := image.WriterConfig{
+		BatchSize:      env.WRITER_BATCH_SIZE,
+		FlushInterval:  env.WRITER_FLUSH_INTERVAL,
+		MaxConcurrency: env.WRITER_MAX_CONCURRENCY,
+		MaxRetries:     env.WRITER_MAX_RETRIES,
+		DeadLetterPath: env.DEADLETTER_FILE,
+	}
+	if cfg.DeadLetterPath == "" {
+		cfg.DeadLetterPath = filepath.Join(wd, "deadletter.jsonl")
 	}
+	maxConns := int(pool.Config().MaxConns)
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = max(1, maxConns/2)
+	}
+	cfg.MaxConcurrency = min(cfg.MaxConcurrency, maxConns)
+	return cfg
+}
 
-	switch method {
-	case "create":
-		create(sd, addrService, proxy)
-	case "read":
-		read(addrService, logger)
+// newStorage returns the storage backend selected by STORAGE_BACKEND
+func newStorage(env *godotenv.Env, dir string) (storage.Storage, error) {
+	switch env.STORAGE_BACKEND {
+	case "", filesystem.Name:
+		return filesystem.NewStorage(dir)
+	case s3.Name:
+		return s3.NewStorage(s3.Config{
+			Endpoint:  env.S3_ENDPOINT,
+			Bucket:    env.S3_BUCKET,
+			AccessKey: env.S3_ACCESS_KEY,
+			SecretKey: env.S3_SECRET_KEY,
+			Region:    env.S3_REGION,
+			UseSSL:    env.S3_USE_SSL,
+			Prefix:    env.S3_PREFIX,
+			PublicURL: env.S3_PUBLIC_URL,
+		})
 	default:
-		fmt.Println("Invalid method. Please enter 'create' or 'read'.")
+		return nil, fmt.Errorf("unknown storage backend %q, use %s or %s", env.STORAGE_BACKEND, filesystem.Name, s3.Name)
 	}
 }
 
-func getMethodAndProxyFromStdin() (string, bool, error) {
-	reader := bufio.NewReader(os.Stdin)
+// close flushes the images still queued in the image service and closes the pool
+func (a *app) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.imageService.Close(ctx); err != nil {
+		a.logger.Error(fmt.Errorf("flushing queued images: %w", err))
+	}
+	a.pool.Close()
+}
 
-	fmt.Print("Please enter the method (create|read): ")
-	method, err := reader.ReadString('\n')
-	if err != nil {
-		return "", false, err
+// Boot runs the subcommand given in os.Args and returns the process exit code
+func Boot() int {
+	//the first SIGINT/SIGTERM cancels ctx so the command can shut down gracefully,
+	//a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return run(ctx, os.Args[1:])
+}
+
+// run runs the subcommand named by the first of args with the rest of them
+// and returns the exit code
+func run(ctx context.Context, args []string) int {
+	if len(args) < 1 {
+		printUsage()
+		return exitUsage
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return exitOK
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		return exitUsage
 	}
-	method = strings.TrimSpace(method)
+	return exitCode(cmd.run(ctx, args[1:]))
+}
 
-	var proxy bool
-	if method == "create" {
-		fmt.Print("Do you want to use proxy? (yes|no) : ")
-		proxyStr, err := reader.ReadString('\n')
-		if err != nil {
-			return "", false, err
-		}
-		proxyStr = strings.TrimSpace(proxyStr)
-		proxy = (proxyStr == "yes")
+// exitCode reports err and returns the exit code it ends the process with
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errInterrupted):
+		fmt.Fprintln(os.Stderr, err)
+		return exitInterrupted
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	default:
+		zerolog.NewLogger().Error(err)
+		return exitError
 	}
+}
 
-	return method, proxy, nil
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: sco <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun 'sco <command> --help' for the flags of a command")
 }
 
-func read(addrService *image.Service, logger logger.Logger) {
-	for {
-		count, err := getCountFromStdin()
-		if err != nil {
-			log.Fatalf("Error getting count from stdin: %v", err)
-		}
-		ch := make(chan *entity.Image, 50)
-		startTime := time.Now()
-		go addrService.Read(count, ch)
-		for img := range ch {
-			logger.Info(fmt.Sprintf("read image: %s", img.File))
-		}
+// parseFlags parses args into fs, turning parse failures into usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	return parseFlagsWithArgs(fs, args, 0)
+}
 
-		elapsedTime := time.Since(startTime)
-		fmt.Printf("Time taken: %s\n", elapsedTime)
+// parseFlagsWithArgs is parseFlags for commands that take exactly n positional arguments after the flags
+func parseFlagsWithArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		//the flag package already printed the error and the defaults
+		return errUsage
+	}
+	if fs.NArg() != n {
+		return fmt.Errorf("%w: expected %d arguments, got %v", errUsage, n, fs.Args())
 	}
+	return nil
 }
 
-func create(sd string, addrService *image.Service, proxy bool) {
-	for {
-		count, err := getCountFromStdin()
-		if err != nil {
-			log.Fatalf("Error getting count from stdin: %v", err)
-		}
-		dr := imgDown.NewDownloadResizer(sd, count, zerolog.NewLogger(), proxy)
+func usageErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
 
-		startTime := time.Now()
-		done := make(chan bool)
-		addrService.Create(dr, done)
-		<-done
-		elapsedTime := time.Since(startTime)
-		fmt.Printf("Time taken: %s\n", elapsedTime)
-	}
+// stringsFlag collects every value of a repeatable flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
 }
 
-func getCountFromStdin() (uint64, error) {
-	fmt.Print("Please enter the images count you want: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return 0, err
-	}
-	countStr := strings.TrimSpace(input)
-	count, err := strconv.Atoi(countStr)
-	if err != nil {
-		return 0, err
-	}
-	if count < 0 {
-		return 0, errors.New("count number must be positive")
-	}
-	return uint64(count), nil
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
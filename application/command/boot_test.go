@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	//every case fails or stops before the command connects to the database
+	tests := []struct {
+		name     string
+		args     []string
+		mustCode int
+	}{
+		{name: "NoCommand", args: nil, mustCode: exitUsage},
+		{name: "Help", args: []string{"help"}, mustCode: exitOK},
+		{name: "HelpFlag", args: []string{"--help"}, mustCode: exitOK},
+		{name: "UnknownCommand", args: []string{"scrape"}, mustCode: exitUsage},
+		{name: "CommandHelp", args: []string{"create", "--help"}, mustCode: exitOK},
+		{name: "UnknownFlag", args: []string{"create", "--count", "5", "--colour", "red"}, mustCode: exitUsage},
+		{name: "MissingCount", args: []string{"create"}, mustCode: exitUsage},
+		{name: "BadCount", args: []string{"create", "--count", "abc"}, mustCode: exitUsage},
+		{name: "NegativeCount", args: []string{"create", "--count", "-5"}, mustCode: exitUsage},
+		{name: "UnknownEngine", args: []string{"create", "--count", "5", "--engines", "altavista"}, mustCode: exitUsage},
+		{name: "BadFormat", args: []string{"create", "--count", "5", "--format", "gif"}, mustCode: exitUsage},
+		{name: "BadQuality", args: []string{"create", "--count", "5", "--quality", "101"}, mustCode: exitUsage},
+		{name: "BadVariant", args: []string{"create", "--count", "5", "--variant", "size=64"}, mustCode: exitUsage},
+		{name: "BadHostRate", args: []string{"create", "--count", "5", "--host-rate", "-1"}, mustCode: exitUsage},
+		{name: "CreateExtraArg", args: []string{"create", "--count", "5", "dog"}, mustCode: exitUsage},
+		{name: "MissingJobID", args: []string{"resume"}, mustCode: exitUsage},
+		{name: "BadJobID", args: []string{"resume", "abc"}, mustCode: exitUsage},
+		{name: "ZeroJobID", args: []string{"resume", "0"}, mustCode: exitUsage},
+		{name: "ReadMissingCount", args: []string{"read"}, mustCode: exitUsage},
+		{name: "BadReadMode", args: []string{"read", "--count", "5", "--mode", "random"}, mustCode: exitUsage},
+		{name: "AdoptWithoutRepair", args: []string{"fsck", "--adopt-orphans"}, mustCode: exitUsage},
+		{name: "NegativeOrphanAge", args: []string{"fsck", "--min-orphan-age", "-1h"}, mustCode: exitUsage},
+		{name: "BadOrphanAge", args: []string{"fsck", "--min-orphan-age", "soon"}, mustCode: exitUsage},
+		{name: "ServeExtraArg", args: []string{"serve", "extra"}, mustCode: exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := run(context.Background(), tt.args); code != tt.mustCode {
+				t.Errorf("exit code of %v is %d, must be %d", tt.args, code, tt.mustCode)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		mustCode int
+	}{
+		{name: "Nil", err: nil, mustCode: exitOK},
+		{name: "Help", err: flag.ErrHelp, mustCode: exitOK},
+		{name: "Interrupted", err: fmt.Errorf("job 1: %w", errInterrupted), mustCode: exitInterrupted},
+		{name: "Usage", err: usageErrorf("--count must be greater than zero"), mustCode: exitUsage},
+		{name: "Error", err: errors.New("connection refused"), mustCode: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.err); code != tt.mustCode {
+				t.Errorf("exit code of %v is %d, must be %d", tt.err, code, tt.mustCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"scrapper/domain/entity"
+	"scrapper/infrastructure/log/zerolog"
+	"scrapper/infrastructure/storage"
+	imgDown "scrapper/utils/image"
+	"strings"
+	"time"
+)
+
+func runCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	count := fs.Uint64("count", 0, "number of images to download (required)")
+	proxy := fs.Bool("proxy", false, "download images through scraped free proxies")
+	var queryFlags stringsFlag
+	fs.Var(&queryFlags, "query", `search query, repeatable, "<query>;<weight>" gives it a weight`)
+	queriesFile := fs.String("queries-file", "", `file with one "<query>[;<weight>]" per line`)
+	maxDistance := fs.Int("max-distance", imgDown.DefaultMaxHammingDistance,
+		"maximum hamming distance between perceptual hashes of near duplicate images, negative disables near duplicate detection")
+	engines := fs.String("engines", "", "comma separated search engines to scrape, all of "+strings.Join(imgDown.ProviderNames(), ",")+" by default")
+	disabledEngines := fs.String("disable-engines", "", "comma separated search engines not to scrape")
+	outputVariants := addOutputFlags(fs)
+	sourceFilter := addFilterFlags(fs)
+	storageLayout := addLayoutFlags(fs)
+	rateLimits := addRateLimitFlags(fs)
+	politeness := addPoliteFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *count == 0 {
+		return usageErrorf("--count must be greater than zero")
+	}
+	queries, err := loadQueryList(queryFlags, *queriesFile)
+	if err != nil {
+		return err
+	}
+	providers, err := imgDown.ResolveProviders(splitList(*engines), splitList(*disabledEngines))
+	if err != nil {
+		return usageErrorf("%s", err)
+	}
+	variants, err := outputVariants()
+	if err != nil {
+		return err
+	}
+	filter, err := sourceFilter()
+	if err != nil {
+		return err
+	}
+	layout, err := storageLayout()
+	if err != nil {
+		return err
+	}
+	imageHostLimits, searchHostLimits, err := rateLimits()
+	if err != nil {
+		return err
+	}
+	polite, err := politeness()
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return create(ctx, a, createOptions{
+		count:            *count,
+		proxy:            *proxy,
+		queries:          queries,
+		providers:        providers,
+		variants:         variants,
+		filter:           filter,
+		layout:           layout,
+		imageHostLimits:  &imageHostLimits,
+		searchHostLimits: &searchHostLimits,
+		polite:           polite,
+		maxDistance:      *maxDistance,
+	})
+}
+
+// loadQueryList merges the --query flags with the queries file, it returns
+// nil when neither is given so the downloader falls back to its defaults
+func loadQueryList(queryFlags []string, queriesFile string) (*imgDown.QueryList, error) {
+	queries := make([]imgDown.Query, 0, len(queryFlags))
+	for _, text := range queryFlags {
+		q, err := imgDown.ParseQuery(text)
+		if err != nil {
+			return nil, usageErrorf("--query: %s", err)
+		}
+		queries = append(queries, q)
+	}
+	if queriesFile != "" {
+		fileQueries, err := imgDown.LoadQueries(queriesFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading queries file: %w", err)
+		}
+		if len(fileQueries) == 0 {
+			return nil, fmt.Errorf("queries file %s: %w", queriesFile, imgDown.ErrNoQueries)
+		}
+		queries = append(queries, fileQueries...)
+	}
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	return imgDown.NewQueryList(queries)
+}
+
+type createOptions struct {
+	count       uint64
+	proxy       bool
+	queries     *imgDown.QueryList
+	providers   []imgDown.SearchProvider
+	variants    []imgDown.Variant
+	filter      imgDown.Filter
+	layout      imgDown.Layout
+	maxDistance int
+	// imageHostLimits and searchHostLimits throttle the requests of every
+	// host, the defaults of the downloader are used when nil
+	imageHostLimits  *imgDown.HostLimits
+	searchHostLimits *imgDown.HostLimits
+	// polite turns on polite mode, nil scrapes without it
+	polite *imgDown.Politeness
+	// job is set when an existing job is resumed
+	job *entity.Job
+	// onStart is called with the job once it is started or resumed
+	onStart func(*entity.Job)
+	// onEvent receives the progress events of the downloader
+	onEvent func(imgDown.Event)
+	// hashIndex holds the hashes of the stored images, create loads a new one
+	// when nil. The jobs of the server share one index
+	hashIndex *imgDown.HashIndex
+	// logOutput logs the progress of the job instead of printing it to stdout
+	logOutput bool
+}
+
+// create runs a create job until its target count is reached or ctx is cancelled
+func create(ctx context.Context, a *app, opts createOptions) error {
+	setupCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	hashIndex := opts.hashIndex
+	if hashIndex == nil {
+		var err error
+		if hashIndex, err = prepare(setupCtx, a, opts.maxDistance); err != nil {
+			return err
+		}
+	}
+	report := func(format string, args ...any) {
+		if opts.logOutput {
+			a.logger.Info(fmt.Sprintf(format, args...))
+			return
+		}
+		fmt.Printf(format+"\n", args...)
+	}
+
+	if opts.queries == nil {
+		opts.queries = imgDown.DefaultQueryList()
+	}
+	if len(opts.providers) == 0 {
+		providers, err := imgDown.ResolveProviders(nil, nil)
+		if err != nil {
+			return err
+		}
+		opts.providers = providers
+	}
+	job := opts.job
+	var savedCount uint64
+	var seenURLs []string
+	var err error
+	if job == nil {
+		job, err = a.jobService.Start(setupCtx, opts.count, toJobQueries(opts.queries), providerNames(opts.providers))
+		if err != nil {
+			return fmt.Errorf("starting job: %w", err)
+		}
+		report("Started job %d, resume it with 'sco resume %d' if the run is interrupted", job.ID, job.ID)
+	} else {
+		savedCount, seenURLs, err = a.jobService.ResumeState(setupCtx, job.ID)
+		if err != nil {
+			return fmt.Errorf("loading state of job %d: %w", job.ID, err)
+		}
+		report("Resuming job %d at %d/%d images (%d urls already tried)", job.ID, savedCount, job.TargetCount, len(seenURLs))
+	}
+	if opts.onStart != nil {
+		opts.onStart(job)
+	}
+	recorder := a.jobService.NewSeenURLRecorder(job.ID)
+
+	dr := imgDown.NewDownloadResizer(imgDown.Config{
+		Storage:          a.storage,
+		TargetCount:      job.TargetCount,
+		Proxy:            opts.proxy,
+		Queries:          opts.queries,
+		Providers:        opts.providers,
+		Variants:         opts.variants,
+		Filter:           opts.filter,
+		Layout:           &opts.layout,
+		ImageHostLimits:  opts.imageHostLimits,
+		SearchHostLimits: opts.searchHostLimits,
+		Polite:           opts.polite,
+		HashIndex:        hashIndex,
+		JobID:            job.ID,
+		InitialCount:     savedCount,
+		SeenURLs:         seenURLs,
+		OnSeenURL:        recorder.Record,
+		OnEvent:          opts.onEvent,
+	}, zerolog.NewLogger())
+
+	startTime := time.Now()
+	done := make(chan bool)
+	a.imageService.Create(ctx, dr, done)
+	<-done
+	recorder.Close()
+
+	//ctx may be cancelled already, the queued images and the progress must be saved anyway
+	flushCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.imageService.FlushJob(flushCtx, job.ID); err != nil {
+		a.logger.Error(fmt.Errorf("flushing queued images of job %d: %w", job.ID, err))
+	}
+	report("Time taken: %s", time.Since(startTime))
+	report("Stats: %s", dr.Stats())
+	report("Writer: %s", a.imageService.FlushStats())
+
+	status := entity.JobStatusCompleted
+	if ctx.Err() != nil {
+		status = entity.JobStatusInterrupted
+	} else if dr.Exhausted() {
+		status = entity.JobStatusExhausted
+	}
+	if err := a.jobService.UpdateProgress(flushCtx, job.ID, status); err != nil {
+		return err
+	}
+	switch status {
+	case entity.JobStatusInterrupted:
+		return fmt.Errorf("job %d %w, resume it with 'sco resume %d'", job.ID, errInterrupted, job.ID)
+	case entity.JobStatusExhausted:
+		return fmt.Errorf("job %d: %w before reaching %d images", job.ID, imgDown.ErrQueriesExhausted, job.TargetCount)
+	}
+	return nil
+}
+
+// prepare loads the hashes of the stored images into a new index and removes
+// the temp files that interrupted runs left behind
+func prepare(ctx context.Context, a *app, maxDistance int) (*imgDown.HashIndex, error) {
+	hashIndex := imgDown.NewHashIndex(maxDistance)
+	if err := a.imageService.LoadHashIndex(ctx, hashIndex); err != nil {
+		return nil, fmt.Errorf("loading stored image hashes: %w", err)
+	}
+	a.logger.Info(fmt.Sprintf("loaded %d stored image hashes", hashIndex.Len()))
+
+	if sweeper, ok := a.storage.(storage.Sweeper); ok {
+		removed, err := sweeper.SweepTemp(ctx, staleTempAge)
+		if err != nil {
+			return nil, fmt.Errorf("removing orphaned temp files: %w", err)
+		}
+		if removed > 0 {
+			a.logger.Info(fmt.Sprintf("removed %d orphaned temp files", removed))
+		}
+	}
+	return hashIndex, nil
+}
+
+func toJobQueries(ql *imgDown.QueryList) []entity.JobQuery {
+	queries := make([]entity.JobQuery, 0, len(ql.Queries()))
+	for _, q := range ql.Queries() {
+		queries = append(queries, entity.JobQuery{Text: q.Text, Weight: q.Weight})
+	}
+	return queries
+}
+
+func fromJobQueries(jobQueries []entity.JobQuery) (*imgDown.QueryList, error) {
+	queries := make([]imgDown.Query, 0, len(jobQueries))
+	for _, q := range jobQueries {
+		queries = append(queries, imgDown.Query{Text: q.Text, Weight: q.Weight})
+	}
+	return imgDown.NewQueryList(queries)
+}
+
+// addOutputFlags adds the flags of the main output variant and of the extra
+// variants to fs, the returned func builds the variants once fs is parsed
+func addOutputFlags(fs *flag.FlagSet) func() ([]imgDown.Variant, error) {
+	def := imgDown.DefaultVariant()
+	format := fs.String("format", string(def.Format), "output format of the main file: jpeg, png or webp (lossless)")
+	quality := fs.Int("quality", def.Quality, "jpeg quality of the main file, from 1 to 100")
+	mode := fs.String("resize", string(def.Mode), "resize mode of the main file: fit-width, fit-height, fit, fill or none")
+	width := fs.Uint("width", def.Width, "width of the main file")
+	height := fs.Uint("height", 0, "height of the main file, used by fit-height, fit and fill (fill defaults to a square)")
+	var variantFlags stringsFlag
+	fs.Var(&variantFlags, "variant", `extra output stored for every image, repeatable, e.g. "name=thumb,format=webp,mode=fill,width=64"`)
+
+	return func() ([]imgDown.Variant, error) {
+		main := imgDown.Variant{
+			Name:    def.Name,
+			Format:  imgDown.Format(*format),
+			Quality: *quality,
+			Mode:    imgDown.ResizeMode(*mode),
+			Width:   *width,
+			Height:  *height,
+		}
+		if main.Mode == imgDown.ResizeFill && main.Height == 0 {
+			main.Height = main.Width
+		}
+		variants := []imgDown.Variant{main}
+		for _, spec := range variantFlags {
+			v, err := imgDown.ParseVariant(spec)
+			if err != nil {
+				return nil, usageErrorf("--variant: %s", err)
+			}
+			variants = append(variants, v)
+		}
+		if err := imgDown.ValidateVariants(variants); err != nil {
+			return nil, usageErrorf("%s", err)
+		}
+		return variants, nil
+	}
+}
+
+// addFilterFlags adds the source image filter flags to fs, the returned func
+// builds the filter once fs is parsed
+func addFilterFlags(fs *flag.FlagSet) func() (imgDown.Filter, error) {
+	def := imgDown.DefaultFilter()
+	minWidth := fs.Int("min-width", 0, "minimum width of source images")
+	minHeight := fs.Int("min-height", 0, "minimum height of source images")
+	maxWidth := fs.Int("max-width", 0, "maximum width of source images, 0 is no limit")
+	maxHeight := fs.Int("max-height", 0, "maximum height of source images, 0 is no limit")
+	minAspect := fs.Float64("min-aspect", 0, "minimum width/height ratio of source images")
+	maxAspect := fs.Float64("max-aspect", 0, "maximum width/height ratio of source images, 0 is no limit")
+	maxBytes := fs.Int64("max-bytes", def.MaxBytes, "maximum download size of source images in bytes, 0 is no limit")
+	allowedTypes := fs.String("allowed-types", strings.Join(def.AllowedTypes, ","), "comma separated MIME types of accepted source images, empty accepts any")
+
+	return func() (imgDown.Filter, error) {
+		filter := imgDown.Filter{
+			MinWidth:       *minWidth,
+			MinHeight:      *minHeight,
+			MaxWidth:       *maxWidth,
+			MaxHeight:      *maxHeight,
+			MinAspectRatio: *minAspect,
+			MaxAspectRatio: *maxAspect,
+			MaxBytes:       *maxBytes,
+			AllowedTypes:   splitList(*allowedTypes),
+		}
+		if err := filter.Validate(); err != nil {
+			return imgDown.Filter{}, usageErrorf("%s", err)
+		}
+		return filter, nil
+	}
+}
+
+// addLayoutFlags adds the storage layout flags to fs, the returned func
+// builds the layout once fs is parsed
+func addLayoutFlags(fs *flag.FlagSet) func() (imgDown.Layout, error) {
+	def := imgDown.DefaultLayout()
+	levels := fs.Int("shard-levels", def.ShardLevels, "number of nested directories files are sharded into, 0 stores them flat")
+	width := fs.Int("shard-width", def.ShardWidth, "number of content hash characters naming each shard directory")
+
+	return func() (imgDown.Layout, error) {
+		layout := imgDown.Layout{ShardLevels: *levels, ShardWidth: *width}
+		if err := layout.Validate(); err != nil {
+			return imgDown.Layout{}, usageErrorf("%s", err)
+		}
+		return layout, nil
+	}
+}
+
+// addRateLimitFlags adds the flags throttling the image hosts and the search
+// engines to fs, the returned func builds their limits once fs is parsed
+func addRateLimitFlags(fs *flag.FlagSet) func() (imgDown.HostLimits, imgDown.HostLimits, error) {
+	imageDef := imgDown.DefaultImageHostLimits()
+	imageRate := fs.Float64("host-rate", imageDef.Rate, "image downloads per second from a single host")
+	imageBurst := fs.Int("host-burst", imageDef.Burst, "image downloads sent at once to an idle host")
+	imageInFlight := fs.Int("host-max-in-flight", imageDef.MaxInFlight, "image downloads running at the same time per host, 0 is unlimited")
+	searchDef := imgDown.DefaultSearchHostLimits()
+	searchRate := fs.Float64("search-rate", searchDef.Rate, "search page requests per second to a single search engine host")
+	searchBurst := fs.Int("search-burst", searchDef.Burst, "search page requests sent at once to an idle search engine host")
+	searchInFlight := fs.Int("search-max-in-flight", searchDef.MaxInFlight, "search page requests running at the same time per search engine host, 0 is unlimited")
+
+	return func() (imgDown.HostLimits, imgDown.HostLimits, error) {
+		imageLimits := imageDef
+		imageLimits.Rate, imageLimits.Burst, imageLimits.MaxInFlight = *imageRate, *imageBurst, *imageInFlight
+		if err := imageLimits.Validate(); err != nil {
+			return imageLimits, searchDef, usageErrorf("--host-*: %s", err)
+		}
+		searchLimits := searchDef
+		searchLimits.Rate, searchLimits.Burst, searchLimits.MaxInFlight = *searchRate, *searchBurst, *searchInFlight
+		if err := searchLimits.Validate(); err != nil {
+			return imageLimits, searchLimits, usageErrorf("--search-*: %s", err)
+		}
+		return imageLimits, searchLimits, nil
+	}
+}
+
+// addPoliteFlags adds the flags of polite mode, the returned func returns nil
+// unless --polite is given
+func addPoliteFlags(fs *flag.FlagSet) func() (*imgDown.Politeness, error) {
+	def := imgDown.DefaultPoliteness()
+	polite := fs.Bool("polite", false, "obey robots.txt of the search engines and image hosts, wait between search pages and send --user-agent")
+	userAgent := fs.String("user-agent", def.UserAgent, "user agent identifying the scraper in polite mode")
+	delay := fs.Duration("delay", def.Delay, "wait between two search pages of a host in polite mode")
+	randomDelay := fs.Duration("random-delay", def.RandomDelay, "upper bound of a random wait added to --delay in polite mode")
+
+	return func() (*imgDown.Politeness, error) {
+		if !*polite {
+			return nil, nil
+		}
+		p := imgDown.Politeness{UserAgent: strings.TrimSpace(*userAgent), Delay: *delay, RandomDelay: *randomDelay}
+		if err := p.Validate(); err != nil {
+			return nil, usageErrorf("--polite: %s", err)
+		}
+		return &p, nil
+	}
+}
+
+func providerNames(providers []imgDown.SearchProvider) []string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// splitList splits a comma separated flag value, ignoring empty items
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"scrapper/domain/service/image"
+	"time"
+)
+
+var errInconsistent = errors.New("storage and database are inconsistent")
+
+// defaultMinOrphanAge is far longer than a queued row waits for the batch writer
+const defaultMinOrphanAge = time.Hour
+
+func runFsck(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "delete the rows of missing files, corrupt files with their rows and orphan files")
+	adopt := fs.Bool("adopt-orphans", false, "with --repair, insert rows for orphan files instead of deleting them")
+	minOrphanAge := fs.Duration("min-orphan-age", defaultMinOrphanAge,
+		"files without a row written more recently aren't orphans yet, their rows may still be queued by a running create")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *adopt && !*repair {
+		return usageErrorf("--adopt-orphans needs --repair")
+	}
+	if *minOrphanAge < 0 {
+		return usageErrorf("--min-orphan-age can't be negative")
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	startTime := time.Now()
+	report, err := a.imageService.Fsck(ctx, image.FsckOptions{Repair: *repair, AdoptOrphans: *adopt, MinOrphanAge: *minOrphanAge})
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("fsck %w", errInterrupted)
+		}
+		return err
+	}
+	for _, file := range report.MissingFiles {
+		fmt.Printf("missing file: %s\n", file)
+	}
+	for _, file := range report.OrphanFiles {
+		fmt.Printf("orphan file: %s\n", file)
+	}
+	for _, file := range report.CorruptFiles {
+		fmt.Printf("corrupt file: %s\n", file)
+	}
+	for _, file := range report.PendingFiles {
+		fmt.Printf("pending file: %s\n", file)
+	}
+	fmt.Printf("Time taken: %s\n", time.Since(startTime))
+	fmt.Printf("Fsck: %s\n", report)
+	if !report.Clean() {
+		return errInconsistent
+	}
+	return nil
+}
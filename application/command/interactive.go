@@ -0,0 +1,139 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"scrapper/domain/service/image"
+	imgDown "scrapper/utils/image"
+	"strconv"
+	"strings"
+)
+
+func runInteractive(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("interactive", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	p := newPrompter(ctx)
+	method, proxy, err := getMethodAndProxyFromStdin(p)
+	if err != nil {
+		return err
+	}
+
+	switch method {
+	case "create":
+		for {
+			count, err := getCountFromStdin(p)
+			if err != nil {
+				return err
+			}
+			err = create(ctx, a, createOptions{
+				count:       count,
+				proxy:       proxy,
+				filter:      imgDown.DefaultFilter(),
+				layout:      imgDown.DefaultLayout(),
+				maxDistance: imgDown.DefaultMaxHammingDistance,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	case "read":
+		for {
+			count, err := getCountFromStdin(p)
+			if err != nil {
+				return err
+			}
+			if err := read(ctx, a, count, image.ReadStopAtEnd); err != nil {
+				return err
+			}
+		}
+	default:
+		return usageErrorf("invalid method %q, please enter 'create' or 'read'", method)
+	}
+}
+
+// prompter reads stdin lines in the background so a prompt can be interrupted by a signal
+type prompter struct {
+	ctx   context.Context
+	lines chan string
+	err   error
+}
+
+func newPrompter(ctx context.Context) *prompter {
+	p := &prompter{
+		ctx:   ctx,
+		lines: make(chan string),
+	}
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				p.err = err
+				close(p.lines)
+				return
+			}
+			p.lines <- line
+		}
+	}()
+	return p
+}
+
+func (p *prompter) prompt(question string) (string, error) {
+	fmt.Print(question)
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return "", p.err
+		}
+		return strings.TrimSpace(line), nil
+	case <-p.ctx.Done():
+		fmt.Println()
+		return "", errInterrupted
+	}
+}
+
+func getMethodAndProxyFromStdin(p *prompter) (string, bool, error) {
+	method, err := p.prompt("Please enter the method (create|read): ")
+	if err != nil {
+		return "", false, err
+	}
+
+	var proxy bool
+	if method == "create" {
+		proxyStr, err := p.prompt("Do you want to use proxy? (yes|no) : ")
+		if err != nil {
+			return "", false, err
+		}
+		proxy = (proxyStr == "yes")
+	}
+
+	return method, proxy, nil
+}
+
+func getCountFromStdin(p *prompter) (uint64, error) {
+	countStr, err := p.prompt("Please enter the images count you want: ")
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 {
+		return 0, errors.New("count number must be positive")
+	}
+	return uint64(count), nil
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func runMigrateLayout(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-layout", flag.ContinueOnError)
+	storageLayout := addLayoutFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "only report how many files would be moved")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	layout, err := storageLayout()
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	startTime := time.Now()
+	m, err := a.imageService.MigrateLayout(ctx, layout, *dryRun)
+	fmt.Printf("Time taken: %s\n", time.Since(startTime))
+	fmt.Printf("Files: %s\n", m)
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return fmt.Errorf("layout migration %w, run it again to continue", errInterrupted)
+	}
+	if err != nil {
+		return err
+	}
+	if m.Failed > 0 {
+		return fmt.Errorf("%d files could not be migrated", m.Failed)
+	}
+	return nil
+}
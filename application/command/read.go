@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"scrapper/domain/entity"
+	"scrapper/domain/service/image"
+	"time"
+)
+
+func runRead(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("read", flag.ContinueOnError)
+	count := fs.Uint64("count", 0, "number of images to read (required)")
+	mode := fs.String("mode", string(image.ReadStopAtEnd), fmt.Sprintf("%s stops at the last image, %s starts over from the first one until --count images are read",
+		image.ReadStopAtEnd, image.ReadCircular))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *count == 0 {
+		return usageErrorf("--count must be greater than zero")
+	}
+	if err := image.ReadMode(*mode).Validate(); err != nil {
+		return usageErrorf("--mode: %s", err)
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return read(ctx, a, *count, image.ReadMode(*mode))
+}
+
+func read(ctx context.Context, a *app, count uint64, mode image.ReadMode) error {
+	ch := make(chan *entity.Image, 50)
+	startTime := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.imageService.Read(ctx, count, mode, ch)
+	}()
+	var read uint64
+	for img := range ch {
+		read++
+		a.logger.Info(fmt.Sprintf("read image %d: %s", img.ID, img.File))
+	}
+	err := <-errCh
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return errInterrupted
+	}
+	if err != nil {
+		return err
+	}
+
+	elapsedTime := time.Since(startTime)
+	fmt.Printf("Time taken: %s\n", elapsedTime)
+	if read < count {
+		fmt.Printf("Read all %d stored images, fewer than the %d asked for\n", read, count)
+	}
+	return nil
+}
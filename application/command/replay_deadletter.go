@@ -0,0 +1,37 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func runReplayDeadLetter(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("replay-deadletter", flag.ContinueOnError)
+	file := fs.String("file", "", "dead letter file to replay, DEADLETTER_FILE by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	startTime := time.Now()
+	stats, err := a.imageService.ReplayDeadLetter(ctx, *file)
+	fmt.Printf("Time taken: %s\n", time.Since(startTime))
+	fmt.Printf("Replay: %s\n", stats)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %s", errInterrupted, err)
+		}
+		return err
+	}
+	if stats.DeadLettered > 0 {
+		return fmt.Errorf("%d images failed again and were written back to the dead letter file", stats.DeadLettered)
+	}
+	return nil
+}
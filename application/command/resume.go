@@ -0,0 +1,94 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"scrapper/domain/entity"
+	jobRepo "scrapper/domain/repository/job"
+	imgDown "scrapper/utils/image"
+	"strconv"
+	"time"
+)
+
+func runResume(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("resume", flag.ContinueOnError)
+	proxy := fs.Bool("proxy", false, "download images through scraped free proxies")
+	maxDistance := fs.Int("max-distance", imgDown.DefaultMaxHammingDistance,
+		"maximum hamming distance between perceptual hashes of near duplicate images, negative disables near duplicate detection")
+	outputVariants := addOutputFlags(fs)
+	sourceFilter := addFilterFlags(fs)
+	storageLayout := addLayoutFlags(fs)
+	rateLimits := addRateLimitFlags(fs)
+	politeness := addPoliteFlags(fs)
+	if err := parseFlagsWithArgs(fs, args, 1); err != nil {
+		return err
+	}
+	variants, err := outputVariants()
+	if err != nil {
+		return err
+	}
+	filter, err := sourceFilter()
+	if err != nil {
+		return err
+	}
+	layout, err := storageLayout()
+	if err != nil {
+		return err
+	}
+	imageHostLimits, searchHostLimits, err := rateLimits()
+	if err != nil {
+		return err
+	}
+	polite, err := politeness()
+	if err != nil {
+		return err
+	}
+	jobID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil || jobID < 1 {
+		return usageErrorf("invalid job id %q", fs.Arg(0))
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	job, err := a.jobService.Get(getCtx, jobID)
+	if errors.Is(err, jobRepo.ErrNotFound) {
+		return fmt.Errorf("job %d: %w", jobID, err)
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status == entity.JobStatusCompleted && job.SavedCount >= job.TargetCount {
+		fmt.Printf("Job %d is already completed with %d images\n", job.ID, job.SavedCount)
+		return nil
+	}
+	queries, err := fromJobQueries(job.Queries)
+	if err != nil {
+		return fmt.Errorf("queries of job %d: %w", job.ID, err)
+	}
+	providers, err := imgDown.ResolveProviders(job.Engines, nil)
+	if err != nil {
+		return fmt.Errorf("engines of job %d: %w", job.ID, err)
+	}
+
+	return create(ctx, a, createOptions{
+		proxy:            *proxy,
+		queries:          queries,
+		providers:        providers,
+		variants:         variants,
+		filter:           filter,
+		layout:           layout,
+		imageHostLimits:  &imageHostLimits,
+		searchHostLimits: &searchHostLimits,
+		polite:           polite,
+		maxDistance:      *maxDistance,
+		job:              job,
+	})
+}
@@ -0,0 +1,218 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"scrapper/application/api"
+	"scrapper/application/rpc"
+	"scrapper/domain/entity"
+	"scrapper/domain/service/job"
+	imgDown "scrapper/utils/image"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultServerAddr = ":8080"
+	readHeaderTimeout = 10 * time.Second
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "", "address of the HTTP API, ServerAddr from the environment or "+defaultServerAddr+" by default")
+	grpcAddr := fs.String("grpc-addr", "", "address of the gRPC API, GRPC_ADDR from the environment by default, the gRPC API is off when both are empty")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.close()
+	if *addr == "" {
+		*addr = a.env.ServerAddr
+	}
+	if *addr == "" {
+		*addr = defaultServerAddr
+	}
+	if *grpcAddr == "" {
+		*grpcAddr = a.env.GRPC_ADDR
+	}
+	return serve(ctx, a, *addr, *grpcAddr)
+}
+
+// serve answers the HTTP API on addr and the gRPC API on grpcAddr, unless it
+// is empty, until ctx is cancelled or a server fails. Then it interrupts the
+// running jobs and waits for them to save their progress
+func serve(ctx context.Context, a *app, addr, grpcAddr string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	//the jobs share one index, so they also skip the images of each other
+	setupCtx, setupCancel := context.WithTimeout(ctx, time.Minute)
+	hashIndex, err := prepare(setupCtx, a, imgDown.DefaultMaxHammingDistance)
+	setupCancel()
+	if err != nil {
+		return err
+	}
+	runner := &jobRunner{ctx: ctx, app: a, hashIndex: hashIndex, jobs: &sync.WaitGroup{}}
+	defer runner.jobs.Wait()
+
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	if grpcAddr != "" {
+		grpcListener, err = net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", grpcAddr, err)
+		}
+		grpcServer = grpc.NewServer()
+		rpc.NewServer(a.logger, a.imageService, a.jobService, runner.run).Register(grpcServer)
+	}
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           api.NewServer(a.logger, a.imageService, a.jobService, runner.start).Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		a.logger.Info(fmt.Sprintf("HTTP API listening on %s", addr))
+		errCh <- httpServer.ListenAndServe()
+	}()
+	if grpcServer != nil {
+		go func() {
+			a.logger.Info(fmt.Sprintf("gRPC API listening on %s", grpcAddr))
+			errCh <- grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	select {
+	case err = <-errCh:
+		//one server failed, the other one is stopped as well
+		cancel()
+	case <-ctx.Done():
+	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+		err = shutdownErr
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	return err
+}
+
+// stopGRPC waits for the running calls, they end quickly once their jobs are
+// interrupted, and closes the remaining ones when ctx is done
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// jobRunner runs the jobs started through the APIs, every job is interrupted
+// when the context of the server is done
+type jobRunner struct {
+	ctx       context.Context
+	app       *app
+	hashIndex *imgDown.HashIndex //hashes of the stored images, loaded once per server
+	jobs      *sync.WaitGroup
+}
+
+// options validates req and turns it into the options of create
+func (r *jobRunner) options(req job.Request) (createOptions, error) {
+	opts := createOptions{
+		count:       req.Count,
+		proxy:       req.Proxy,
+		filter:      imgDown.DefaultFilter(),
+		layout:      imgDown.DefaultLayout(),
+		maxDistance: imgDown.DefaultMaxHammingDistance,
+		hashIndex:   r.hashIndex,
+		logOutput:   true,
+	}
+	if req.Count == 0 {
+		return opts, fmt.Errorf("%w: count must be greater than zero", job.ErrInvalidRequest)
+	}
+	if len(req.Queries) > 0 {
+		queries, err := fromJobQueries(req.Queries)
+		if err != nil {
+			return opts, fmt.Errorf("%w: %w", job.ErrInvalidRequest, err)
+		}
+		opts.queries = queries
+	}
+	providers, err := imgDown.ResolveProviders(req.Engines, nil)
+	if err != nil {
+		return opts, fmt.Errorf("%w: %w", job.ErrInvalidRequest, err)
+	}
+	opts.providers = providers
+	return opts, nil
+}
+
+// start runs a job in the background and returns once the job is stored, the
+// job outlives the request that started it
+func (r *jobRunner) start(ctx context.Context, req job.Request) (*entity.Job, error) {
+	opts, err := r.options(req)
+	if err != nil {
+		return nil, err
+	}
+	started := make(chan *entity.Job, 1)
+	failed := make(chan error, 1)
+	jobStarted := false
+	opts.onStart = func(job *entity.Job) {
+		jobStarted = true
+		started <- job
+	}
+	r.jobs.Add(1)
+	go func() {
+		defer r.jobs.Done()
+		err := create(r.ctx, r.app, opts)
+		if !jobStarted {
+			//the request is still waiting and reports the error
+			failed <- err
+			return
+		}
+		if err != nil {
+			r.app.logger.Error(err)
+		}
+	}()
+
+	select {
+	case job := <-started:
+		return job, nil
+	case err := <-failed:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run runs a job until it ends or ctx is done
+func (r *jobRunner) run(ctx context.Context, req job.Request, onStart func(*entity.Job), onEvent func(imgDown.Event)) error {
+	opts, err := r.options(req)
+	if err != nil {
+		return err
+	}
+	opts.onStart = onStart
+	opts.onEvent = onEvent
+	r.jobs.Add(1)
+	defer r.jobs.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(r.ctx, cancel)
+	defer stop()
+	return create(ctx, r.app, opts)
+}
@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: scrapper.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Scrapper_StreamImages_FullMethodName = "/scrapper.v1.Scrapper/StreamImages"
+	Scrapper_CreateImages_FullMethodName = "/scrapper.v1.Scrapper/CreateImages"
+)
+
+// ScrapperClient is the client API for Scrapper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Scrapper reads the stored images and runs create jobs
+type ScrapperClient interface {
+	// StreamImages streams up to count stored images in id order
+	StreamImages(ctx context.Context, in *StreamImagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Image], error)
+	// CreateImages starts a create job and streams its progress until the job
+	// ends, cancelling the call interrupts the job so it can be resumed later
+	CreateImages(ctx context.Context, in *CreateImagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CreateImagesEvent], error)
+}
+
+type scrapperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScrapperClient(cc grpc.ClientConnInterface) ScrapperClient {
+	return &scrapperClient{cc}
+}
+
+func (c *scrapperClient) StreamImages(ctx context.Context, in *StreamImagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Image], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Scrapper_ServiceDesc.Streams[0], Scrapper_StreamImages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamImagesRequest, Image]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scrapper_StreamImagesClient = grpc.ServerStreamingClient[Image]
+
+func (c *scrapperClient) CreateImages(ctx context.Context, in *CreateImagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CreateImagesEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Scrapper_ServiceDesc.Streams[1], Scrapper_CreateImages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CreateImagesRequest, CreateImagesEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scrapper_CreateImagesClient = grpc.ServerStreamingClient[CreateImagesEvent]
+
+// ScrapperServer is the server API for Scrapper service.
+// All implementations must embed UnimplementedScrapperServer
+// for forward compatibility.
+//
+// Scrapper reads the stored images and runs create jobs
+type ScrapperServer interface {
+	// StreamImages streams up to count stored images in id order
+	StreamImages(*StreamImagesRequest, grpc.ServerStreamingServer[Image]) error
+	// CreateImages starts a create job and streams its progress until the job
+	// ends, cancelling the call interrupts the job so it can be resumed later
+	CreateImages(*CreateImagesRequest, grpc.ServerStreamingServer[CreateImagesEvent]) error
+	mustEmbedUnimplementedScrapperServer()
+}
+
+// UnimplementedScrapperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedScrapperServer struct{}
+
+func (UnimplementedScrapperServer) StreamImages(*StreamImagesRequest, grpc.ServerStreamingServer[Image]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamImages not implemented")
+}
+func (UnimplementedScrapperServer) CreateImages(*CreateImagesRequest, grpc.ServerStreamingServer[CreateImagesEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method CreateImages not implemented")
+}
+func (UnimplementedScrapperServer) mustEmbedUnimplementedScrapperServer() {}
+func (UnimplementedScrapperServer) testEmbeddedByValue()                  {}
+
+// UnsafeScrapperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScrapperServer will
+// result in compilation errors.
+type UnsafeScrapperServer interface {
+	mustEmbedUnimplementedScrapperServer()
+}
+
+func RegisterScrapperServer(s grpc.ServiceRegistrar, srv ScrapperServer) {
+	// If the following call pancis, it indicates UnimplementedScrapperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Scrapper_ServiceDesc, srv)
+}
+
+func _Scrapper_StreamImages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamImagesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScrapperServer).StreamImages(m, &grpc.GenericServerStream[StreamImagesRequest, Image]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scrapper_StreamImagesServer = grpc.ServerStreamingServer[Image]
+
+func _Scrapper_CreateImages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CreateImagesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScrapperServer).CreateImages(m, &grpc.GenericServerStream[CreateImagesRequest, CreateImagesEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scrapper_CreateImagesServer = grpc.ServerStreamingServer[CreateImagesEvent]
+
+// Scrapper_ServiceDesc is the grpc.ServiceDesc for Scrapper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Scrapper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "scrapper.v1.Scrapper",
+	HandlerType: (*ScrapperServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamImages",
+			Handler:       _Scrapper_StreamImages_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CreateImages",
+			Handler:       _Scrapper_CreateImages_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "scrapper.proto",
+}
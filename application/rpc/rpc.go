@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scrapper/application/rpc/pb"
+	"scrapper/domain/entity"
+	"scrapper/domain/service/image"
+	"scrapper/domain/service/job"
+	logger "scrapper/infrastructure/log"
+	imgDown "scrapper/utils/image"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventQueueLength is the number of progress events buffered for a slow client
+// before the download waits for it
+const eventQueueLength = 1024
+
+// RunJobFunc runs a create job until it ends. It calls onStart once the job is
+// stored and onEvent for every downloaded, rejected and saved image. Errors
+// caused by the request wrap job.ErrInvalidRequest
+type RunJobFunc func(ctx context.Context, req job.Request, onStart func(*entity.Job), onEvent func(imgDown.Event)) error
+
+// Server implements the Scrapper gRPC service
+type Server struct {
+	pb.UnimplementedScrapperServer
+	logger       logger.Logger
+	imageService *image.Service
+	jobService   *job.Service
+	runJob       RunJobFunc
+}
+
+func NewServer(logger logger.Logger, imageService *image.Service, jobService *job.Service, runJob RunJobFunc) *Server {
+	return &Server{
+		logger:       logger,
+		imageService: imageService,
+		jobService:   jobService,
+		runJob:       runJob,
+	}
+}
+
+// Register adds the service to gs
+func (s *Server) Register(gs *grpc.Server) {
+	pb.RegisterScrapperServer(gs, s)
+}
+
+func (s *Server) StreamImages(req *pb.StreamImagesRequest, stream pb.Scrapper_StreamImagesServer) error {
+	if req.GetCount() == 0 {
+		return status.Error(codes.InvalidArgument, "count must be greater than zero")
+	}
+	mode := image.ReadStopAtEnd
+	if req.GetMode() == pb.ReadMode_READ_MODE_CIRCULAR {
+		mode = image.ReadCircular
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	ch := make(chan *entity.Image)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.imageService.Read(ctx, req.GetCount(), mode, ch)
+	}()
+	var sendErr error
+	for img := range ch {
+		if sendErr != nil {
+			continue
+		}
+		if sendErr = stream.Send(toImage(img)); sendErr != nil {
+			//stop the read and drain ch so it can return
+			cancel()
+		}
+	}
+	err := <-errCh
+	switch {
+	case sendErr != nil:
+		return sendErr
+	case errors.Is(err, image.ErrNoImages):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case err != nil:
+		s.logger.Error(fmt.Errorf("streaming images: %w", err))
+		return status.Error(codes.Internal, "reading images failed")
+	}
+	return nil
+}
+
+func (s *Server) CreateImages(req *pb.CreateImagesRequest, stream pb.Scrapper_CreateImagesServer) error {
+	if req.GetCount() == 0 {
+		return status.Error(codes.InvalidArgument, "count must be greater than zero")
+	}
+	jobReq := job.Request{
+		Count:   req.GetCount(),
+		Engines: req.GetEngines(),
+		Proxy:   req.GetProxy(),
+	}
+	for _, q := range req.GetQueries() {
+		jobReq.Queries = append(jobReq.Queries, entity.JobQuery{Text: q.GetText(), Weight: int(q.GetWeight())})
+	}
+	//an unset weight counts as one, like in the proto
+	jobReq = jobReq.WithDefaults()
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	events := make(chan *pb.CreateImagesEvent, eventQueueLength)
+	send := func(e *pb.CreateImagesEvent) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	}
+	var started *entity.Job
+	var runErr error
+	go func() {
+		//events is closed once the job ended, every callback returned by then
+		defer close(events)
+		runErr = s.runJob(ctx, jobReq, func(j *entity.Job) {
+			started = j
+			send(&pb.CreateImagesEvent{Event: &pb.CreateImagesEvent_Started{Started: &pb.JobStarted{
+				JobId:       j.ID,
+				TargetCount: j.TargetCount,
+			}}})
+		}, func(e imgDown.Event) {
+			send(toEvent(e, req.GetCount()))
+		})
+	}()
+
+	var sendErr error
+	for e := range events {
+		if sendErr != nil {
+			continue
+		}
+		if sendErr = stream.Send(e); sendErr != nil {
+			//the client is gone, interrupt the job and wait for it to save its progress
+			cancel()
+		}
+	}
+	switch {
+	case sendErr != nil:
+		return sendErr
+	case started == nil && errors.Is(runErr, job.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, runErr.Error())
+	case started == nil:
+		s.logger.Error(fmt.Errorf("starting job: %w", runErr))
+		return status.Error(codes.Internal, "starting job failed")
+	}
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if runErr != nil {
+		s.logger.Warning(fmt.Sprintf("job %d: %s", started.ID, runErr))
+	}
+	//the job may end with an error after saving images, its row tells how it ended
+	finished, err := s.jobService.Progress(ctx, started.ID)
+	if err != nil {
+		s.logger.Error(fmt.Errorf("getting job %d: %w", started.ID, err))
+		return status.Error(codes.Internal, "getting job failed")
+	}
+	return stream.Send(&pb.CreateImagesEvent{Event: &pb.CreateImagesEvent_Finished{Finished: &pb.JobFinished{
+		JobId:      finished.ID,
+		Status:     finished.Status,
+		SavedCount: finished.SavedCount,
+	}}})
+}
+
+func toEvent(e imgDown.Event, targetCount uint64) *pb.CreateImagesEvent {
+	switch e.Kind {
+	case imgDown.EventDownloaded:
+		return &pb.CreateImagesEvent{Event: &pb.CreateImagesEvent_Downloaded{Downloaded: &pb.ImageDownloaded{
+			SourceUrl:    e.SourceURL,
+			SearchEngine: e.SearchEngine,
+			Query:        e.Query,
+		}}}
+	case imgDown.EventRejected:
+		return &pb.CreateImagesEvent{Event: &pb.CreateImagesEvent_Rejected{Rejected: &pb.ImageRejected{
+			SourceUrl:    e.SourceURL,
+			SearchEngine: e.SearchEngine,
+			Query:        e.Query,
+			Reason:       e.Reason,
+		}}}
+	default:
+		return &pb.CreateImagesEvent{Event: &pb.CreateImagesEvent_Saved{Saved: &pb.ImageSaved{
+			SourceUrl:    e.SourceURL,
+			SearchEngine: e.SearchEngine,
+			Query:        e.Query,
+			File:         e.File,
+			SavedCount:   e.Count,
+			TargetCount:  targetCount,
+		}}}
+	}
+}
+
+func toImage(img *entity.Image) *pb.Image {
+	variants := make([]*pb.ImageVariant, 0, len(img.Variants))
+	for _, v := range img.Variants {
+		variants = append(variants, &pb.ImageVariant{
+			Name:        v.Name,
+			File:        v.File,
+			Width:       int32(v.Width),
+			Height:      int32(v.Height),
+			ByteSize:    v.ByteSize,
+			ContentType: v.ContentType,
+			Sha256:      v.SHA256,
+		})
+	}
+	return &pb.Image{
+		Id:             img.ID,
+		File:           img.File,
+		StorageBackend: img.StorageBackend,
+		SourceUrl:      img.SourceURL,
+		SearchEngine:   img.SearchEngine,
+		Query:          img.Query,
+		OriginalWidth:  int32(img.OriginalWidth),
+		OriginalHeight: int32(img.OriginalHeight),
+		Width:          int32(img.Width),
+		Height:         int32(img.Height),
+		ByteSize:       img.ByteSize,
+		ContentType:    img.ContentType,
+		Sha256:         img.SHA256,
+		JobId:          img.JobID,
+		Variants:       variants,
+		CreatedAt:      timestamppb.New(img.CreatedAt),
+	}
+}
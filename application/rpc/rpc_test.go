@@ -0,0 +1,243 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"io"
+	"net"
+	"scrapper/application/apitest"
+	"scrapper/application/rpc/pb"
+	"scrapper/domain/entity"
+	"scrapper/domain/service/job"
+	imgDown "scrapper/utils/image"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	*apitest.Services
+	client pb.ScrapperClient
+}
+
+// newTestServer serves the service on an in-process listener and returns a client of it
+func newTestServer(t *testing.T, runJob RunJobFunc) *testServer {
+	ts := &testServer{Services: apitest.NewServices(t)}
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewServer(ts.Logger, ts.ImageService, ts.JobService, runJob).Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	ts.client = pb.NewScrapperClient(conn)
+	return ts
+}
+
+// streamImages makes Stream of the mock call fn with n images
+func streamImages(n int64) func(ctx context.Context, afterID int64, fn func(*entity.Image) error) error {
+	return func(ctx context.Context, afterID int64, fn func(*entity.Image) error) error {
+		for id := afterID + 1; id <= n; id++ {
+			img := &entity.Image{ID: id, File: fmt.Sprintf("%d.jpg", id), CreatedAt: time.Now()}
+			if err := fn(img); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestServer_StreamImages(t *testing.T) {
+	var tests = []struct {
+		name     string
+		req      *pb.StreamImagesRequest
+		stored   int64
+		mustIDs  []int64
+		mustCode codes.Code
+	}{
+		{
+			name:    "StopAtEnd",
+			req:     &pb.StreamImagesRequest{Count: 5},
+			stored:  3,
+			mustIDs: []int64{1, 2, 3},
+		},
+		{
+			name:    "Circular",
+			req:     &pb.StreamImagesRequest{Count: 5, Mode: pb.ReadMode_READ_MODE_CIRCULAR},
+			stored:  3,
+			mustIDs: []int64{1, 2, 3, 1, 2},
+		},
+		{
+			name:    "FewerThanStored",
+			req:     &pb.StreamImagesRequest{Count: 2},
+			stored:  3,
+			mustIDs: []int64{1, 2},
+		},
+		{
+			name:     "Empty",
+			req:      &pb.StreamImagesRequest{Count: 5},
+			mustCode: codes.NotFound,
+		},
+		{
+			name:     "ZeroCount",
+			req:      &pb.StreamImagesRequest{},
+			mustCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTestServer(t, nil)
+			ts.ImageRepoMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(streamImages(test.stored)).AnyTimes()
+
+			stream, err := ts.client.StreamImages(context.Background(), test.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int64, 0)
+			for {
+				img, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					if code := status.Code(err); code != test.mustCode {
+						t.Fatalf("code is %s, must be %s: %s", code, test.mustCode, err)
+					}
+					return
+				}
+				ids = append(ids, img.GetId())
+			}
+			if test.mustCode != codes.OK {
+				t.Fatalf("stream ended without error, must fail with %s", test.mustCode)
+			}
+			if len(ids) != len(test.mustIDs) {
+				t.Fatalf("streamed %v, must stream %v", ids, test.mustIDs)
+			}
+			for i := range ids {
+				if ids[i] != test.mustIDs[i] {
+					t.Fatalf("streamed %v, must stream %v", ids, test.mustIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_CreateImages(t *testing.T) {
+	var got job.Request
+	ts := newTestServer(t, func(ctx context.Context, req job.Request, onStart func(*entity.Job), onEvent func(imgDown.Event)) error {
+		got = req
+		onStart(&entity.Job{ID: 9, TargetCount: req.Count, Status: entity.JobStatusRunning})
+		onEvent(imgDown.Event{Kind: imgDown.EventDownloaded, SourceURL: "https://a/1.jpg", SearchEngine: "bing"})
+		onEvent(imgDown.Event{Kind: imgDown.EventRejected, SourceURL: "https://a/1.jpg", Reason: "too_small"})
+		onEvent(imgDown.Event{Kind: imgDown.EventDownloaded, SourceURL: "https://a/2.jpg", SearchEngine: "bing"})
+		onEvent(imgDown.Event{Kind: imgDown.EventSaved, SourceURL: "https://a/2.jpg", File: "ab/cd/abcd.jpg", Count: 1})
+		return nil
+	})
+	ts.JobRepoMock.EXPECT().Get(gomock.Any(), int64(9)).
+		Return(&entity.Job{ID: 9, TargetCount: 1, SavedCount: 1, Status: entity.JobStatusCompleted}, nil)
+
+	stream, err := ts.client.CreateImages(context.Background(), &pb.CreateImagesRequest{
+		Count:   1,
+		Queries: []*pb.Query{{Text: "cat", Weight: 2}, {Text: "dog"}},
+		Engines: []string{"bing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []*pb.CreateImagesEvent
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	//the query without a weight weighs one
+	if len(got.Queries) != 2 || got.Queries[0] != (entity.JobQuery{Text: "cat", Weight: 2}) || got.Queries[1] != (entity.JobQuery{Text: "dog", Weight: 1}) ||
+		len(got.Engines) != 1 || got.Engines[0] != "bing" || got.Count != 1 {
+		t.Errorf("job request is %+v", got)
+	}
+	if len(events) != 6 {
+		t.Fatalf("got %d events, must get 6: %v", len(events), events)
+	}
+	if started := events[0].GetStarted(); started.GetJobId() != 9 || started.GetTargetCount() != 1 {
+		t.Errorf("first event is %v, must be started", events[0])
+	}
+	if events[1].GetDownloaded().GetSourceUrl() != "https://a/1.jpg" {
+		t.Errorf("second event is %v, must be downloaded", events[1])
+	}
+	if events[2].GetRejected().GetReason() != "too_small" {
+		t.Errorf("third event is %v, must be rejected", events[2])
+	}
+	if saved := events[4].GetSaved(); saved.GetSavedCount() != 1 || saved.GetTargetCount() != 1 || saved.GetFile() != "ab/cd/abcd.jpg" {
+		t.Errorf("fifth event is %v, must be saved", events[4])
+	}
+	if finished := events[5].GetFinished(); finished.GetStatus() != entity.JobStatusCompleted || finished.GetSavedCount() != 1 {
+		t.Errorf("last event is %v, must be finished", events[5])
+	}
+}
+
+func TestServer_CreateImagesInvalid(t *testing.T) {
+	ts := newTestServer(t, func(ctx context.Context, req job.Request, onStart func(*entity.Job), onEvent func(imgDown.Event)) error {
+		return fmt.Errorf("%w: unknown search engine", job.ErrInvalidRequest)
+	})
+
+	for _, req := range []*pb.CreateImagesRequest{{}, {Count: 1, Engines: []string{"altavista"}}} {
+		stream, err := ts.client.CreateImages(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = stream.Recv(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v failed with %v, must fail with %s", req, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestServer_CreateImagesCancel(t *testing.T) {
+	interrupted := make(chan bool, 1)
+	ts := newTestServer(t, func(ctx context.Context, req job.Request, onStart func(*entity.Job), onEvent func(imgDown.Event)) error {
+		onStart(&entity.Job{ID: 9, TargetCount: req.Count, Status: entity.JobStatusRunning})
+		<-ctx.Done()
+		interrupted <- true
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := ts.client.CreateImages(ctx, &pb.CreateImagesRequest{Count: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := stream.Recv(); err != nil || e.GetStarted() == nil {
+		t.Fatalf("first event is %v (%v), must be started", e, err)
+	}
+	cancel()
+	select {
+	case <-interrupted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the call must interrupt the job")
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
-import "scrapper/application/command"
+import (
+	"os"
+	"scrapper/application/command"
+)
 
 func main() {
-	command.Boot()
+	os.Exit(command.Boot())
 }
@@ -1,5 +1,41 @@
 package entity
 
+import "time"
+
 type Image struct {
-	File string //path
+	ID             int64
+	File           string //key of the file in its storage backend
+	StorageBackend string //name of the storage backend holding the files of the image
+	SourceURL      string
+	SearchEngine   string
+	Query          string
+	OriginalWidth  int
+	OriginalHeight int
+	Width          int //stored width
+	Height         int //stored height
+	ByteSize       int64
+	ContentType    string
+	SHA256         string //hex encoded hash of the stored file
+	PixelHash      string //hex encoded hash of the decoded source pixels
+	PerceptualHash uint64 //dHash of the source image
+	JobID          int64  //zero when the image belongs to no job
+	Variants       []ImageVariant
+	CreatedAt      time.Time
+}
+
+// ImageVariant is one stored output of an image, File of the image is its first variant
+type ImageVariant struct {
+	Name        string
+	File        string
+	Width       int
+	Height      int
+	ByteSize    int64
+	ContentType string
+	SHA256      string
+}
+
+// ImageHash holds the hashes used to detect duplicate images
+type ImageHash struct {
+	PixelHash      string
+	PerceptualHash uint64
 }
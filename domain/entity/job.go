@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+const (
+	JobStatusRunning     = "running"
+	JobStatusCompleted   = "completed"
+	JobStatusInterrupted = "interrupted"
+	JobStatusExhausted   = "exhausted" //every query ran out of new results
+)
+
+// Job is a create run that can be resumed after the process dies
+type Job struct {
+	ID          int64
+	TargetCount uint64
+	Queries     []JobQuery
+	Engines     []string
+	SavedCount  uint64 //progress, number of images stored for the job
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type JobQuery struct {
+	Text   string `json:"text"`
+	Weight int    `json:"weight"`
+}
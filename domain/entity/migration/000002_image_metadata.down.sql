@@ -0,0 +1,12 @@
+ALTER TABLE images
+    DROP COLUMN IF EXISTS source_url,
+    DROP COLUMN IF EXISTS search_engine,
+    DROP COLUMN IF EXISTS query,
+    DROP COLUMN IF EXISTS original_width,
+    DROP COLUMN IF EXISTS original_height,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS byte_size,
+    DROP COLUMN IF EXISTS content_type,
+    DROP COLUMN IF EXISTS sha256,
+    DROP COLUMN IF EXISTS created_at;
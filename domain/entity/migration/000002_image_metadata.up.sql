@@ -0,0 +1,12 @@
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS source_url      text,
+    ADD COLUMN IF NOT EXISTS search_engine   text,
+    ADD COLUMN IF NOT EXISTS query           text,
+    ADD COLUMN IF NOT EXISTS original_width  integer,
+    ADD COLUMN IF NOT EXISTS original_height integer,
+    ADD COLUMN IF NOT EXISTS width           integer,
+    ADD COLUMN IF NOT EXISTS height          integer,
+    ADD COLUMN IF NOT EXISTS byte_size       bigint,
+    ADD COLUMN IF NOT EXISTS content_type    varchar(100),
+    ADD COLUMN IF NOT EXISTS sha256          char(64),
+    ADD COLUMN IF NOT EXISTS created_at      timestamptz NOT NULL DEFAULT now();
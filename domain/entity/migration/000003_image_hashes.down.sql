@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS images_pixel_hash_idx;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS pixel_hash,
+    DROP COLUMN IF EXISTS perceptual_hash;
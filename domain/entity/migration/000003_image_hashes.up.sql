@@ -0,0 +1,7 @@
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS pixel_hash      char(64),
+    ADD COLUMN IF NOT EXISTS perceptual_hash bigint;
+
+CREATE UNIQUE INDEX IF NOT EXISTS images_pixel_hash_idx ON images (pixel_hash);
+-- perceptual_hash has no index, near duplicates are found by the hamming distance
+-- of the hashes, which a btree can't serve, every hash is loaded into the HashIndex instead
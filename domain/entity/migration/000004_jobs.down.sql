@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS images_job_id_idx;
+
+ALTER TABLE images
+    DROP COLUMN IF EXISTS job_id;
+
+DROP TABLE IF EXISTS job_seen_urls;
+DROP TABLE IF EXISTS jobs;
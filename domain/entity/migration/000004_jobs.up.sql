@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS jobs (
+                                    id           bigserial PRIMARY KEY,
+                                    target_count bigint NOT NULL,
+                                    queries      jsonb NOT NULL,
+                                    engines      text[] NOT NULL,
+                                    saved_count  bigint NOT NULL DEFAULT 0,
+                                    status       varchar(20) NOT NULL,
+                                    created_at   timestamptz NOT NULL DEFAULT now(),
+                                    updated_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS job_seen_urls (
+                                    job_id bigint NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+                                    url    text NOT NULL,
+                                    PRIMARY KEY (job_id, url)
+);
+
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS job_id bigint REFERENCES jobs (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS images_job_id_idx ON images (job_id);
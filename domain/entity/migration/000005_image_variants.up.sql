@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS image_variants (
+                                    pixel_hash   char(64) NOT NULL REFERENCES images (pixel_hash) ON DELETE CASCADE,
+                                    name         varchar(50) NOT NULL,
+                                    file         varchar(200) NOT NULL,
+                                    width        integer NOT NULL,
+                                    height       integer NOT NULL,
+                                    byte_size    bigint NOT NULL,
+                                    content_type varchar(100) NOT NULL,
+                                    sha256       char(64) NOT NULL,
+                                    created_at   timestamptz NOT NULL DEFAULT now(),
+                                    PRIMARY KEY (pixel_hash, name)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE images
+    ADD COLUMN IF NOT EXISTS storage_backend varchar(20) NOT NULL DEFAULT 'filesystem';
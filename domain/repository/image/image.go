@@ -9,9 +9,28 @@ import (
 var (
 	ErrAlreadyExist = errors.New("already exist")
 	ErrNotFound     = errors.New("not found")
+	// ErrTransient wraps errors that may go away when the call is retried,
+	// such as lost connections, timeouts and serialization failures
+	ErrTransient = errors.New("transient error")
 )
 
 type Image interface {
 	CreateBatch(context.Context, []*entity.Image) error
-	List(context.Context, uint64) ([]*entity.Image, error)
+	// Get returns the image with id or ErrNotFound
+	Get(ctx context.Context, id int64) (*entity.Image, error)
+	// List returns up to limit images with an id greater than afterID ordered
+	// by id, a limit of zero or less returns a page of the default size
+	List(ctx context.Context, afterID int64, limit int) ([]*entity.Image, error)
+	// Stream calls fn with every image with an id greater than afterID ordered
+	// by id. An error returned by fn stops the stream and is returned
+	Stream(ctx context.Context, afterID int64, fn func(*entity.Image) error) error
+	ListHashes(context.Context) ([]*entity.ImageHash, error)
+	// StreamFiles calls fn with the file of every image and image variant kept
+	// in the storage backend, rows are streamed so any number of them can be read.
+	// An error returned by fn stops the stream and is returned
+	StreamFiles(ctx context.Context, backend string, fn func(file string) error) error
+	// RenameFile points every image and image variant stored in oldFile to newFile
+	RenameFile(ctx context.Context, oldFile, newFile string) error
+	// DeleteFile deletes the images and image variants stored in file
+	DeleteFile(ctx context.Context, file string) error
 }
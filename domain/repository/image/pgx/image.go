@@ -2,59 +2,369 @@ package pgx
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+	"net"
 	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	"strings"
+	"time"
 )
 
 const pageSize = 10
+const defaultStorageBackend = "filesystem"
+
+// copyThreshold is the batch size from which CreateBatch uses COPY instead of INSERT statements
+const copyThreshold = 100
+
+// uniqueViolation is the postgres error code of a duplicate key
+const uniqueViolation = "23505"
+
+// nullable columns are coalesced so rows created before the metadata migration can be scanned.
+// The variants of every image are aggregated into a json array whose keys are
+// the fields of entity.ImageVariant
+const selectColumns = `id, file, COALESCE(source_url, ''), COALESCE(search_engine, ''), COALESCE(query, ''),
+	COALESCE(original_width, 0), COALESCE(original_height, 0), COALESCE(width, 0), COALESCE(height, 0),
+	COALESCE(byte_size, 0), COALESCE(content_type, ''), COALESCE(sha256, ''),
+	COALESCE(pixel_hash, ''), COALESCE(perceptual_hash, 0), COALESCE(job_id, 0), storage_backend, created_at,
+	COALESCE((SELECT json_agg(json_build_object('Name', v.name, 'File', v.file, 'Width', v.width, 'Height', v.height,
+		'ByteSize', v.byte_size, 'ContentType', v.content_type, 'SHA256', v.sha256) ORDER BY v.name)
+		FROM image_variants v WHERE v.pixel_hash = images.pixel_hash), '[]')`
 
 type ImageRepository struct {
-	conn *pgxpool.Pool
+	conn          *pgxpool.Pool
+	copyThreshold int //zero disables COPY
 }
 
 func NewImageRepository(conn *pgxpool.Pool) *ImageRepository {
 	ur := &ImageRepository{
-		conn: conn,
+		conn:          conn,
+		copyThreshold: copyThreshold,
 	}
 	return ur
 }
 
-func (r ImageRepository) List(ctx context.Context, offset uint64) ([]*entity.Image, error) {
-	images := make([]*entity.Image, 0)
-	rows, err := r.conn.Query(ctx, `SELECT * FROM images LIMIT $1 OFFSET $2 `, pageSize, offset)
+func scanImage(row pgx.Row, image *entity.Image) error {
+	//perceptual hashes are stored in a signed bigint column
+	var perceptualHash int64
+	var variants []byte
+	err := row.Scan(&image.ID, &image.File, &image.SourceURL, &image.SearchEngine, &image.Query,
+		&image.OriginalWidth, &image.OriginalHeight, &image.Width, &image.Height,
+		&image.ByteSize, &image.ContentType, &image.SHA256,
+		&image.PixelHash, &perceptualHash, &image.JobID, &image.StorageBackend, &image.CreatedAt, &variants)
+	if err != nil {
+		return err
+	}
+	image.PerceptualHash = uint64(perceptualHash)
+	if err := json.Unmarshal(variants, &image.Variants); err != nil {
+		return fmt.Errorf("decoding variants of image %d: %w", image.ID, err)
+	}
+	return nil
+}
+
+// hashArgs returns the values of the hash columns, images without a pixel hash
+// get NULLs so they never conflict with each other on the unique index
+func hashArgs(image *entity.Image) (any, any) {
+	if image.PixelHash == "" {
+		return nil, nil
+	}
+	return image.PixelHash, int64(image.PerceptualHash)
+}
+
+func jobIDArg(image *entity.Image) any {
+	if image.JobID == 0 {
+		return nil
+	}
+	return image.JobID
+}
+
+func (r ImageRepository) Get(ctx context.Context, id int64) (*entity.Image, error) {
+	image := &entity.Image{}
+	err := scanImage(r.conn.QueryRow(ctx, `SELECT `+selectColumns+` FROM images WHERE id = $1`, id), image)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, imageRepo.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	return image, nil
+}
+
+func (r ImageRepository) List(ctx context.Context, afterID int64, limit int) ([]*entity.Image, error) {
+	if limit <= 0 {
+		limit = pageSize
+	}
+	images := make([]*entity.Image, 0, limit)
+	err := r.stream(ctx, `SELECT `+selectColumns+` FROM images WHERE id > $1 ORDER BY id LIMIT $2`,
+		[]any{afterID, limit}, func(image *entity.Image) error {
+			images = append(images, image)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Stream runs a single keyset query, pgx hands the rows to fn as they arrive
+// from the server so memory use doesn't grow with the number of rows
+func (r ImageRepository) Stream(ctx context.Context, afterID int64, fn func(*entity.Image) error) error {
+	return r.stream(ctx, `SELECT `+selectColumns+` FROM images WHERE id > $1 ORDER BY id`, []any{afterID}, fn)
+}
+
+func (r ImageRepository) stream(ctx context.Context, sql string, args []any, fn func(*entity.Image) error) error {
+	rows, err := r.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 	for rows.Next() {
 		image := &entity.Image{}
-		if err := rows.Scan(&image.File); err != nil {
-			return nil, err
+		if err := scanImage(rows, image); err != nil {
+			return err
+		}
+		if err := fn(image); err != nil {
+			return err
 		}
-		images = append(images, image)
 	}
+	return rows.Err()
+}
 
-	if rows.Err() != nil {
+func (r ImageRepository) ListHashes(ctx context.Context) ([]*entity.ImageHash, error) {
+	hashes := make([]*entity.ImageHash, 0)
+	rows, err := r.conn.Query(ctx, `SELECT pixel_hash, COALESCE(perceptual_hash, 0) FROM images WHERE pixel_hash IS NOT NULL`)
+	if err != nil {
 		return nil, err
 	}
-	return images, nil
+	defer rows.Close()
+	for rows.Next() {
+		hash := &entity.ImageHash{}
+		var perceptualHash int64
+		if err := rows.Scan(&hash.PixelHash, &perceptualHash); err != nil {
+			return nil, err
+		}
+		hash.PerceptualHash = uint64(perceptualHash)
+		hashes = append(hashes, hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (r ImageRepository) StreamFiles(ctx context.Context, backend string, fn func(file string) error) error {
+	rows, err := r.conn.Query(ctx, `SELECT file FROM images WHERE storage_backend = $1
+		UNION SELECT v.file FROM image_variants v JOIN images i USING (pixel_hash) WHERE i.storage_backend = $1`, backend)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var file string
+		if err := rows.Scan(&file); err != nil {
+			return err
+		}
+		if err := fn(file); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r ImageRepository) RenameFile(ctx context.Context, oldFile, newFile string) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	images, err := tx.Exec(ctx, `UPDATE images SET file = $2 WHERE file = $1`, oldFile, newFile)
+	if err != nil {
+		return err
+	}
+	variants, err := tx.Exec(ctx, `UPDATE image_variants SET file = $2 WHERE file = $1`, oldFile, newFile)
+	if err != nil {
+		return err
+	}
+	if images.RowsAffected()+variants.RowsAffected() == 0 {
+		return imageRepo.ErrNotFound
+	}
+	return tx.Commit(ctx)
 }
 
+func (r ImageRepository) DeleteFile(ctx context.Context, file string) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	//variants of the deleted images are removed by the foreign key cascade
+	images, err := tx.Exec(ctx, `DELETE FROM images WHERE file = $1`, file)
+	if err != nil {
+		return err
+	}
+	variants, err := tx.Exec(ctx, `DELETE FROM image_variants WHERE file = $1`, file)
+	if err != nil {
+		return err
+	}
+	if images.RowsAffected()+variants.RowsAffected() == 0 {
+		return imageRepo.ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// storageBackendArg defaults the backend of images created without one to
+// the local filesystem, where every file was stored before backends existed
+func storageBackendArg(image *entity.Image) string {
+	if image.StorageBackend == "" {
+		return defaultStorageBackend
+	}
+	return image.StorageBackend
+}
+
+// CreateBatch inserts the images, rows whose pixel hash is already stored are
+// skipped and reported with an error wrapping imageRepo.ErrAlreadyExist.
+// Batches of copyThreshold images or more are copied with the COPY protocol,
+// if the copy fails on a duplicate the batch is inserted row by row instead
 func (r ImageRepository) CreateBatch(ctx context.Context, images []*entity.Image) error {
+	err := r.createBatch(ctx, images)
+	if err != nil && transient(err) {
+		return fmt.Errorf("%w: %w", imageRepo.ErrTransient, err)
+	}
+	return err
+}
+
+// transient reports whether err is a failure of the connection or of the
+// server that a later retry of the same statements may not hit
+func transient(err error) bool {
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"): //connection exception
+			return true
+		case pgErr.Code == "40001", pgErr.Code == "40P01": //serialization failure, deadlock
+			return true
+		case pgErr.Code == "53300": //too many connections
+			return true
+		case pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03": //server shutting down or starting
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (r ImageRepository) createBatch(ctx context.Context, images []*entity.Image) error {
+	for _, image := range images {
+		if image.CreatedAt.IsZero() {
+			image.CreatedAt = time.Now()
+		}
+	}
+	if r.copyThreshold > 0 && len(images) >= r.copyThreshold {
+		err := r.copyBatch(ctx, images)
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+			return err
+		}
+	}
+	return r.insertBatch(ctx, images)
+}
+
+// copyBatch copies the images and their variants in one transaction, nothing
+// is stored when any image is a duplicate
+func (r ImageRepository) copyBatch(ctx context.Context, images []*entity.Image) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"images"}, []string{"file", "source_url", "search_engine", "query",
+		"original_width", "original_height", "width", "height", "byte_size", "content_type", "sha256",
+		"pixel_hash", "perceptual_hash", "job_id", "storage_backend", "created_at"},
+		pgx.CopyFromSlice(len(images), func(i int) ([]any, error) {
+			image := images[i]
+			pixelHash, perceptualHash := hashArgs(image)
+			return []any{image.File, image.SourceURL, image.SearchEngine, image.Query,
+				image.OriginalWidth, image.OriginalHeight, image.Width, image.Height,
+				image.ByteSize, image.ContentType, image.SHA256, pixelHash, perceptualHash,
+				jobIDArg(image), storageBackendArg(image), image.CreatedAt}, nil
+		}))
+	if err != nil {
+		return err
+	}
+
+	variants := make([][]any, 0, len(images))
+	for _, image := range images {
+		//variants reference the pixel hash, images without one have no variant rows
+		if image.PixelHash == "" {
+			continue
+		}
+		for _, v := range image.Variants {
+			variants = append(variants, []any{image.PixelHash, v.Name, v.File, v.Width, v.Height, v.ByteSize, v.ContentType, v.SHA256})
+		}
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"image_variants"}, []string{"pixel_hash", "name", "file",
+		"width", "height", "byte_size", "content_type", "sha256"}, pgx.CopyFromRows(variants))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertBatch inserts the images one statement at a time in a pgx.Batch, skipping duplicates
+func (r ImageRepository) insertBatch(ctx context.Context, images []*entity.Image) error {
 	batch := &pgx.Batch{}
+	//variant inserts are queued between the image inserts, only the image results count duplicates
+	isImage := make([]bool, 0, len(images))
 
 	for _, image := range images {
-		sql := `INSERT INTO images (file) VALUES($1)`
-		batch.Queue(sql, image.File)
+		pixelHash, perceptualHash := hashArgs(image)
+		sql := `INSERT INTO images (file, source_url, search_engine, query, original_width, original_height,
+			width, height, byte_size, content_type, sha256, pixel_hash, perceptual_hash, job_id, storage_backend, created_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			ON CONFLICT (pixel_hash) DO NOTHING`
+		batch.Queue(sql, image.File, image.SourceURL, image.SearchEngine, image.Query,
+			image.OriginalWidth, image.OriginalHeight, image.Width, image.Height,
+			image.ByteSize, image.ContentType, image.SHA256, pixelHash, perceptualHash, jobIDArg(image), storageBackendArg(image), image.CreatedAt)
+		isImage = append(isImage, true)
+
+		if pixelHash == nil {
+			continue
+		}
+		for _, v := range image.Variants {
+			//the variant is only recorded when the image row above was inserted and not skipped as a duplicate
+			sql := `INSERT INTO image_variants (pixel_hash, name, file, width, height, byte_size, content_type, sha256)
+				SELECT $1, $2, $3, $4, $5, $6, $7, $8
+				WHERE EXISTS (SELECT 1 FROM images WHERE pixel_hash = $1 AND file = $9)
+				ON CONFLICT DO NOTHING`
+			batch.Queue(sql, pixelHash, v.Name, v.File, v.Width, v.Height, v.ByteSize, v.ContentType, v.SHA256, image.File)
+			isImage = append(isImage, false)
+		}
 	}
 	br := r.conn.SendBatch(ctx, batch)
 	defer br.Close()
 
+	duplicates := 0
 	for i := 0; i < batch.Len(); i++ {
-		_, err := br.Exec()
+		tag, err := br.Exec()
 		if err != nil {
 			return err
 		}
+		if isImage[i] && tag.RowsAffected() == 0 {
+			duplicates++
+		}
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("%d of %d images skipped: %w", duplicates, len(images), imageRepo.ErrAlreadyExist)
 	}
 
 	return nil
@@ -0,0 +1,20 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"scrapper/domain/entity"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+)
+
+type Job interface {
+	Create(context.Context, *entity.Job) error
+	Get(context.Context, int64) (*entity.Job, error)
+	UpdateProgress(ctx context.Context, id int64, status string) error
+	CountImages(context.Context, int64) (uint64, error)
+	AddSeenURLs(ctx context.Context, id int64, urls []string) error
+	ListSeenURLs(context.Context, int64) ([]string, error)
+}
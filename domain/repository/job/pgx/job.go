@@ -0,0 +1,88 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"scrapper/domain/entity"
+	jobRepo "scrapper/domain/repository/job"
+)
+
+type JobRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewJobRepository(conn *pgxpool.Pool) *JobRepository {
+	return &JobRepository{
+		conn: conn,
+	}
+}
+
+func (r JobRepository) Create(ctx context.Context, job *entity.Job) error {
+	return r.conn.QueryRow(ctx, `INSERT INTO jobs (target_count, queries, engines, status)
+		VALUES($1, $2, $3, $4) RETURNING id, created_at, updated_at`,
+		job.TargetCount, job.Queries, job.Engines, job.Status).
+		Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+}
+
+func (r JobRepository) Get(ctx context.Context, id int64) (*entity.Job, error) {
+	job := &entity.Job{}
+	err := r.conn.QueryRow(ctx, `SELECT id, target_count, queries, engines, saved_count, status, created_at, updated_at
+		FROM jobs WHERE id = $1`, id).
+		Scan(&job.ID, &job.TargetCount, &job.Queries, &job.Engines, &job.SavedCount,
+			&job.Status, &job.CreatedAt, &job.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, jobRepo.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// UpdateProgress sets the status and refreshes saved_count from the images of the job
+func (r JobRepository) UpdateProgress(ctx context.Context, id int64, status string) error {
+	tag, err := r.conn.Exec(ctx, `UPDATE jobs SET status = $2, updated_at = now(),
+		saved_count = (SELECT count(*) FROM images WHERE job_id = $1)
+		WHERE id = $1`, id, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return jobRepo.ErrNotFound
+	}
+	return nil
+}
+
+func (r JobRepository) CountImages(ctx context.Context, id int64) (uint64, error) {
+	var count uint64
+	err := r.conn.QueryRow(ctx, `SELECT count(*) FROM images WHERE job_id = $1`, id).Scan(&count)
+	return count, err
+}
+
+func (r JobRepository) AddSeenURLs(ctx context.Context, id int64, urls []string) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO job_seen_urls (job_id, url)
+		SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING`, id, urls)
+	return err
+}
+
+func (r JobRepository) ListSeenURLs(ctx context.Context, id int64) ([]string, error) {
+	urls := make([]string, 0)
+	rows, err := r.conn.Query(ctx, `SELECT url FROM job_seen_urls WHERE job_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
@@ -0,0 +1,188 @@
+package image
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	"sync"
+	"time"
+)
+
+// maxDeadLetterLine is the longest line read back from a dead letter file
+const maxDeadLetterLine = 1 << 20
+
+// deadLetter appends the images of batches that could not be stored to a
+// JSON lines file, one image per line, so they can be replayed later
+type deadLetter struct {
+	path string
+	mtx  *sync.Mutex
+}
+
+func (d deadLetter) write(images []*entity.Image) error {
+	if d.path == "" {
+		return errors.New("no dead letter file configured")
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	f, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, image := range images {
+		if err = enc.Encode(image); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReplayStats counts the images handled by ReplayDeadLetter
+type ReplayStats struct {
+	Replayed     int
+	Duplicates   int //batches in which some images were already stored
+	DeadLettered int //images that failed again and were written back to the dead letter file
+}
+
+func (r ReplayStats) String() string {
+	return fmt.Sprintf("replayed %d, batches with duplicates %d, failed again %d", r.Replayed, r.Duplicates, r.DeadLettered)
+}
+
+// ReplayDeadLetter stores the images of a dead letter file again, an empty
+// path replays the dead letter file of the service. That file is moved aside
+// first so images dead lettered meanwhile by a running create are kept, the
+// images that fail again are appended to a new dead letter file. The replayed
+// file is removed once every image of it is handled
+func (s Service) ReplayDeadLetter(ctx context.Context, path string) (ReplayStats, error) {
+	var stats ReplayStats
+	replaying := path
+	if path == "" || path == s.deadLetter.path {
+		replaying = fmt.Sprintf("%s.replay-%d", s.deadLetter.path, time.Now().UnixNano())
+		if err := os.Rename(s.deadLetter.path, replaying); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return stats, nil
+			}
+			return stats, err
+		}
+	}
+	f, err := os.Open(replaying)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	batch := make([]*entity.Image, 0, s.writerCfg.BatchSize)
+	store := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := s.createBatch(ctx, batch)
+		switch {
+		case errors.Is(err, imageRepo.ErrAlreadyExist):
+			stats.Duplicates++
+			stats.Replayed += len(batch)
+		case err != nil:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Error(err)
+			if err := s.deadLetter.write(batch); err != nil {
+				return err
+			}
+			stats.DeadLettered += len(batch)
+		default:
+			stats.Replayed += len(batch)
+		}
+		batch = make([]*entity.Image, 0, s.writerCfg.BatchSize)
+		return nil
+	}
+
+	err = scanDeadLetter(f, replaying, func(image *entity.Image) error {
+		batch = append(batch, image)
+		if len(batch) >= s.writerCfg.BatchSize {
+			if err := store(); err != nil {
+				return fmt.Errorf("replay stopped, replay %s again to continue: %w", replaying, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	if err := store(); err != nil {
+		return stats, fmt.Errorf("replay stopped, replay %s again to continue: %w", replaying, err)
+	}
+	f.Close()
+	return stats, os.Remove(replaying)
+}
+
+// scanDeadLetter calls fn with every image of the dead letter file r named
+// name, an error returned by fn stops the scan and is returned
+func scanDeadLetter(r io.Reader, name string, fn func(*entity.Image) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxDeadLetterLine)
+	for scanner.Scan() {
+		image := &entity.Image{}
+		if err := json.Unmarshal(scanner.Bytes(), image); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := fn(image); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// files returns the files of the dead lettered images, including the ones of
+// dead letter files a replay moved aside and didn't finish. A replay stores
+// their rows later, so these files are not orphans
+func (d deadLetter) files() (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+	if d.path == "" {
+		return files, nil
+	}
+	replaying, err := filepath.Glob(d.path + ".replay-*")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range append([]string{d.path}, replaying...) {
+		err := d.readFiles(path, files)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func (d deadLetter) readFiles(path string, files map[string]struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return scanDeadLetter(f, path, func(image *entity.Image) error {
+		files[image.File] = struct{}{}
+		for _, v := range image.Variants {
+			files[v.File] = struct{}{}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,125 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"os"
+	"path/filepath"
+	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"testing"
+	"time"
+)
+
+func TestService_createBatchRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	transientErr := fmt.Errorf("%w: connection reset", imageRepo.ErrTransient)
+	permanentErr := errors.New("invalid input")
+
+	var tests = []struct {
+		name         string
+		results      []error
+		mustRetries  int
+		mustErr      error
+		mustAttempts int
+	}{
+		{
+			name:         "transient errors are retried",
+			results:      []error{transientErr, transientErr, nil},
+			mustRetries:  2,
+			mustAttempts: 3,
+		},
+		{
+			name:         "retries give up after MaxRetries",
+			results:      []error{transientErr, transientErr, transientErr, transientErr},
+			mustRetries:  3,
+			mustErr:      imageRepo.ErrTransient,
+			mustAttempts: 4,
+		},
+		{
+			name:         "other errors are not retried",
+			results:      []error{permanentErr},
+			mustErr:      permanentErr,
+			mustAttempts: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			repoMock := mock_image.NewMockImage(ctrl)
+			repoMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).AnyTimes().
+				DoAndReturn(func(ctx context.Context, images []*entity.Image) error {
+					attempts++
+					return test.results[attempts-1]
+				})
+			loggerMock := mock_log.NewMockLog(ctrl)
+			loggerMock.EXPECT().Warning(gomock.Any()).AnyTimes()
+
+			service := NewService(loggerMock, repoMock, nil, WriterConfig{MaxRetries: 3, RetryBackoff: time.Millisecond})
+			retries, err := service.createBatch(context.Background(), []*entity.Image{{File: "1"}})
+			if retries != test.mustRetries || attempts != test.mustAttempts {
+				t.Errorf("%d retries in %d attempts, must be %d in %d", retries, attempts, test.mustRetries, test.mustAttempts)
+			}
+			if !errors.Is(err, test.mustErr) {
+				t.Errorf("error is %v, must be %v", err, test.mustErr)
+			}
+		})
+	}
+}
+
+func TestService_ReplayDeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	path := filepath.Join(t.TempDir(), "deadletter.jsonl")
+	err := errors.New("error")
+	failing := true
+	stored := make([]*entity.Image, 0)
+	repoMock := mock_image.NewMockImage(ctrl)
+	repoMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, images []*entity.Image) error {
+			if failing {
+				return err
+			}
+			stored = append(stored, images...)
+			return nil
+		})
+	loggerMock := mock_log.NewMockLog(ctrl)
+	loggerMock.EXPECT().Error(err).Times(2)
+
+	service := NewService(loggerMock, repoMock, nil, WriterConfig{BatchSize: 2, DeadLetterPath: path})
+	for i := 0; i < 3; i++ {
+		service.enqueue(&entity.Image{File: fmt.Sprint(i), Variants: []entity.ImageVariant{{Name: "default", File: fmt.Sprint(i)}}})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := service.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats := service.FlushStats(); stats.DeadLettered != 3 {
+		t.Fatalf("dead lettered %d images, must be 3", stats.DeadLettered)
+	}
+
+	failing = false
+	replay, err := service.ReplayDeadLetter(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Replayed != 3 || len(stored) != 3 {
+		t.Errorf("replayed %d, stored %d images, must be 3", replay.Replayed, len(stored))
+	}
+	if len(stored) > 0 && (len(stored[0].Variants) != 1 || stored[0].Variants[0].Name != "default") {
+		t.Errorf("variants of the replayed image are %+v, must be kept", stored[0].Variants)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("dead letter file must be removed after the replay, stat error is %v", err)
+	}
+}
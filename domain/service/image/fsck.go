@@ -0,0 +1,171 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	goImage "image"
+	"io"
+	"net/http"
+	"scrapper/domain/entity"
+	"scrapper/utils/image"
+	"time"
+)
+
+type FsckOptions struct {
+	// Repair deletes the rows of missing files, the corrupt files with their
+	// rows and the orphan files
+	Repair bool
+	// AdoptOrphans inserts rows for orphan files that decode instead of deleting them, it needs Repair
+	AdoptOrphans bool
+	// MinOrphanAge keeps the files without a row written within this time out
+	// of the orphans, their rows may still be queued by a running create
+	MinOrphanAge time.Duration
+}
+
+// FsckReport lists the inconsistencies found between the rows and the storage
+type FsckReport struct {
+	Rows  int
+	Files int
+	// MissingFiles are files of rows that are not in the storage
+	MissingFiles []string
+	// OrphanFiles are stored files no row refers to
+	OrphanFiles []string
+	// CorruptFiles are stored files that fail to decode as an image
+	CorruptFiles []string
+	// PendingFiles are stored files without a row yet that are newer than
+	// MinOrphanAge or dead lettered, they are never repaired
+	PendingFiles []string
+	Repaired     int
+	RepairFailed int
+}
+
+func (r FsckReport) String() string {
+	return fmt.Sprintf("%d rows, %d files: %d missing, %d orphan, %d corrupt, %d pending, %d repaired, %d repairs failed",
+		r.Rows, r.Files, len(r.MissingFiles), len(r.OrphanFiles), len(r.CorruptFiles), len(r.PendingFiles), r.Repaired, r.RepairFailed)
+}
+
+// Clean reports whether no inconsistency is left
+func (r FsckReport) Clean() bool {
+	return len(r.MissingFiles)+len(r.OrphanFiles)+len(r.CorruptFiles) == r.Repaired
+}
+
+// Fsck compares the rows of the images kept in the storage backend with the
+// stored files and decodes every file, with opts.Repair the problems are fixed.
+// Files named in the dead letter file of the service are not orphans, a replay
+// stores their rows
+func (s Service) Fsck(ctx context.Context, opts FsckOptions) (FsckReport, error) {
+	var report FsckReport
+	deadLettered, err := s.deadLetter.files()
+	if err != nil {
+		return report, fmt.Errorf("reading dead letter files: %w", err)
+	}
+	cutoff := time.Now().Add(-opts.MinOrphanAge)
+	rows := make(map[string]bool) //file -> found in the storage
+	err = s.imageRepo.StreamFiles(ctx, s.storage.Name(), func(file string) error {
+		rows[file] = false
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("streaming rows: %w", err)
+	}
+	report.Rows = len(rows)
+
+	err = s.storage.Walk(ctx, func(key string, modTime time.Time) error {
+		report.Files++
+		_, hasRow := rows[key]
+		if hasRow {
+			rows[key] = true
+		}
+		data, err := s.readStored(ctx, key)
+		if err != nil {
+			return err
+		}
+		img, _, err := goImage.Decode(bytes.NewReader(data))
+		switch {
+		case err != nil:
+			s.logger.Warning(fmt.Sprintf("corrupt file %s: %s", key, err))
+			report.CorruptFiles = append(report.CorruptFiles, key)
+			if opts.Repair {
+				s.countRepair(&report, key, s.deleteCorrupt(ctx, key, hasRow))
+			}
+		case !hasRow && (isDeadLettered(deadLettered, key) || modTime.After(cutoff)):
+			report.PendingFiles = append(report.PendingFiles, key)
+		case !hasRow:
+			report.OrphanFiles = append(report.OrphanFiles, key)
+			if opts.Repair && opts.AdoptOrphans {
+				s.countRepair(&report, key, s.adopt(ctx, key, img, data))
+			} else if opts.Repair {
+				s.countRepair(&report, key, s.storage.Delete(ctx, key))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("walking storage: %w", err)
+	}
+
+	for file, found := range rows {
+		if found {
+			continue
+		}
+		report.MissingFiles = append(report.MissingFiles, file)
+		if opts.Repair {
+			s.countRepair(&report, file, s.imageRepo.DeleteFile(ctx, file))
+		}
+	}
+	return report, nil
+}
+
+func isDeadLettered(files map[string]struct{}, key string) bool {
+	_, ok := files[key]
+	return ok
+}
+
+func (s Service) countRepair(report *FsckReport, file string, err error) {
+	if err != nil {
+		report.RepairFailed++
+		s.logger.Error(fmt.Errorf("repairing %s: %w", file, err))
+		return
+	}
+	report.Repaired++
+}
+
+func (s Service) readStored(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (s Service) deleteCorrupt(ctx context.Context, key string, hasRow bool) error {
+	if hasRow {
+		if err := s.imageRepo.DeleteFile(ctx, key); err != nil {
+			return err
+		}
+	}
+	return s.storage.Delete(ctx, key)
+}
+
+// adopt inserts a row for an orphan file, only what the file itself tells is known
+func (s Service) adopt(ctx context.Context, key string, img goImage.Image, data []byte) error {
+	sum := sha256.Sum256(data)
+	return s.imageRepo.CreateBatch(ctx, []*entity.Image{{
+		File:           key,
+		StorageBackend: s.storage.Name(),
+		OriginalWidth:  img.Bounds().Dx(),
+		OriginalHeight: img.Bounds().Dy(),
+		Width:          img.Bounds().Dx(),
+		Height:         img.Bounds().Dy(),
+		ByteSize:       int64(len(data)),
+		ContentType:    http.DetectContentType(data),
+		SHA256:         hex.EncodeToString(sum[:]),
+		PixelHash:      image.PixelHash(img),
+		PerceptualHash: image.DifferenceHash(img),
+		CreatedAt:      time.Now(),
+	}})
+}
@@ -0,0 +1,209 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	goImage "image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"scrapper/domain/entity"
+	"scrapper/infrastructure/storage/filesystem"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_Fsck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	rows := []string{"ok.png", "corrupt.jpg", "missing.jpg"}
+
+	var tests = []struct {
+		name          string
+		opts          FsckOptions
+		ImageRepoMock func() *mock_image.MockImage
+		mustRepaired  int
+		mustFiles     []string //files left in the storage
+	}{
+		{
+			name: "report only",
+			ImageRepoMock: func() *mock_image.MockImage {
+				return mock_image.NewMockImage(ctrl)
+			},
+			mustFiles: []string{"corrupt.jpg", "ok.png", "orphan.png"},
+		},
+		{
+			name: "repair deletes orphans",
+			opts: FsckOptions{Repair: true},
+			ImageRepoMock: func() *mock_image.MockImage {
+				repoMock := mock_image.NewMockImage(ctrl)
+				repoMock.EXPECT().DeleteFile(gomock.Any(), "corrupt.jpg").Return(nil)
+				repoMock.EXPECT().DeleteFile(gomock.Any(), "missing.jpg").Return(nil)
+				return repoMock
+			},
+			mustRepaired: 3,
+			mustFiles:    []string{"ok.png"},
+		},
+		{
+			name: "repair adopts orphans",
+			opts: FsckOptions{Repair: true, AdoptOrphans: true},
+			ImageRepoMock: func() *mock_image.MockImage {
+				repoMock := mock_image.NewMockImage(ctrl)
+				repoMock.EXPECT().DeleteFile(gomock.Any(), "corrupt.jpg").Return(nil)
+				repoMock.EXPECT().DeleteFile(gomock.Any(), "missing.jpg").Return(nil)
+				repoMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return(nil)
+				return repoMock
+			},
+			mustRepaired: 3,
+			mustFiles:    []string{"ok.png", "orphan.png"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFsckFiles(t, root)
+			st, err := filesystem.NewStorage(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imageRepoMock := test.ImageRepoMock()
+			imageRepoMock.EXPECT().StreamFiles(gomock.Any(), filesystem.Name, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, backend string, fn func(string) error) error {
+					for _, file := range rows {
+						if err := fn(file); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			loggerMock := mock_log.NewMockLog(ctrl)
+			loggerMock.EXPECT().Warning(gomock.Any()).AnyTimes()
+
+			service := NewService(loggerMock, imageRepoMock, st, WriterConfig{})
+			report, err := service.Fsck(context.Background(), test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Rows != 3 || report.Files != 3 {
+				t.Errorf("checked %d rows and %d files, must check 3 and 3", report.Rows, report.Files)
+			}
+			if len(report.MissingFiles) != 1 || report.MissingFiles[0] != "missing.jpg" {
+				t.Errorf("missing files are %v, must be [missing.jpg]", report.MissingFiles)
+			}
+			if len(report.OrphanFiles) != 1 || report.OrphanFiles[0] != "orphan.png" {
+				t.Errorf("orphan files are %v, must be [orphan.png]", report.OrphanFiles)
+			}
+			if len(report.CorruptFiles) != 1 || report.CorruptFiles[0] != "corrupt.jpg" {
+				t.Errorf("corrupt files are %v, must be [corrupt.jpg]", report.CorruptFiles)
+			}
+			if report.Repaired != test.mustRepaired || report.RepairFailed != 0 {
+				t.Errorf("repaired %d, failed %d, must repair %d", report.Repaired, report.RepairFailed, test.mustRepaired)
+			}
+
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			sort.Strings(files)
+			if len(files) != len(test.mustFiles) {
+				t.Fatalf("files left are %v, must be %v", files, test.mustFiles)
+			}
+			for i := range files {
+				if files[i] != test.mustFiles[i] {
+					t.Fatalf("files left are %v, must be %v", files, test.mustFiles)
+				}
+			}
+		})
+	}
+}
+
+func writeFsckFiles(t *testing.T, root string) {
+	encoded := &bytes.Buffer{}
+	if err := png.Encode(encoded, goImage.NewGray(goImage.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"ok.png":      encoded.Bytes(),
+		"orphan.png":  encoded.Bytes(),
+		"corrupt.jpg": []byte("truncated"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestService_FsckPendingFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	root := t.TempDir()
+	encoded := &bytes.Buffer{}
+	if err := png.Encode(encoded, goImage.NewGray(goImage.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"orphan.png", "recent.png", "deadlettered.png", "replaying.png"} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, encoded.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "recent.png" {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	st, err := filesystem.NewStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imageRepoMock := mock_image.NewMockImage(ctrl)
+	imageRepoMock.EXPECT().StreamFiles(gomock.Any(), filesystem.Name, gomock.Any()).Return(nil)
+	deadLetterPath := filepath.Join(t.TempDir(), "deadletter.jsonl")
+	service := NewService(mock_log.NewMockLog(ctrl), imageRepoMock, st, WriterConfig{DeadLetterPath: deadLetterPath})
+	//a row waiting for a replay and one of a replay that was interrupted
+	if err := service.deadLetter.write([]*entity.Image{{File: "gone.jpg", Variants: []entity.ImageVariant{{Name: "thumb", File: "deadlettered.png"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	replaying := deadLetter{path: deadLetterPath + ".replay-1", mtx: &sync.Mutex{}}
+	if err := replaying.write([]*entity.Image{{File: "replaying.png"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.Fsck(context.Background(), FsckOptions{Repair: true, MinOrphanAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.OrphanFiles) != 1 || report.OrphanFiles[0] != "orphan.png" || report.Repaired != 1 {
+		t.Errorf("orphan files are %v and %d repaired, must delete only orphan.png", report.OrphanFiles, report.Repaired)
+	}
+	pending := append([]string{}, report.PendingFiles...)
+	sort.Strings(pending)
+	if fmt.Sprint(pending) != "[deadlettered.png recent.png replaying.png]" {
+		t.Errorf("pending files are %v, must be the recent and the dead lettered ones", pending)
+	}
+	for _, name := range pending {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("pending file %s must be kept: %v", name, err)
+		}
+	}
+	if !report.Clean() {
+		t.Errorf("report %s must be clean, pending files aren't inconsistent", report)
+	}
+}
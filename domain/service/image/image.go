@@ -3,98 +3,257 @@ package image
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"scrapper/domain/entity"
 	imageRepo "scrapper/domain/repository/image"
 	logger "scrapper/infrastructure/log"
+	"scrapper/infrastructure/storage"
 	"scrapper/utils/image"
+	"sync"
 	"time"
 )
 
-const maxCreateWorkerCount = 1000
 const workerQueueLength = 20000
+const flushPollInterval = 50 * time.Millisecond
 
 var ErrServiceUnavailable = errors.New("service unavailable")
 
+var (
+	ErrNoImages        = errors.New("no images stored")
+	ErrInvalidReadMode = errors.New("invalid read mode")
+	// ErrOtherBackend is returned for the files of images stored by another storage backend
+	ErrOtherBackend = errors.New("image is stored in another storage backend")
+)
+
+// MaxListLimit is the largest page returned by List
+const MaxListLimit = 1000
+
+// errReadDone stops the stream of Read once enough images were read
+var errReadDone = errors.New("read done")
+
 type Service struct {
-	imageRepo        imageRepo.Image
-	logger           logger.Logger
-	createQueue      chan *entity.Image
-	storageDirectory string
+	imageRepo   imageRepo.Image
+	logger      logger.Logger
+	createQueue chan *entity.Image
+	storage     storage.Storage
+	workers     *sync.WaitGroup
+	pending     *pendingImages
+	writerCfg   WriterConfig
+	flushNow    chan struct{}
+	flushStats  *flushStats
+	deadLetter  deadLetter
 }
 
-func NewService(logger logger.Logger, imageRepo imageRepo.Image, storageDirectory string) *Service {
+func NewService(logger logger.Logger, imageRepo imageRepo.Image, storage storage.Storage, writerCfg WriterConfig) *Service {
 	s := &Service{
-		imageRepo:        imageRepo,
-		logger:           logger,
-		createQueue:      make(chan *entity.Image, workerQueueLength),
-		storageDirectory: storageDirectory,
+		imageRepo:   imageRepo,
+		logger:      logger,
+		createQueue: make(chan *entity.Image, workerQueueLength),
+		storage:     storage,
+		workers:     &sync.WaitGroup{},
+		pending:     &pendingImages{jobs: make(map[int64]int64)},
+		writerCfg:   writerCfg.withDefaults(),
+		flushNow:    make(chan struct{}, 1),
+		flushStats:  &flushStats{},
+		deadLetter:  deadLetter{path: writerCfg.DeadLetterPath, mtx: &sync.Mutex{}},
 	}
-	s.startWorkers()
+	s.workers.Add(1)
+	go s.runWriter()
 	return s
 }
 
-func (s Service) startWorkers() {
-	for i := 0; i < maxCreateWorkerCount; i++ {
-		go s.createWorker()
-	}
+// Flush waits until every image queued so far is flushed to the repository
+func (s Service) Flush(ctx context.Context) error {
+	return s.waitFlushed(ctx, s.pending.total)
 }
 
-func (s Service) createWorker() {
-	imageBatch := make([]*entity.Image, 0)
-	t := time.NewTicker(time.Second)
-	for {
+// FlushJob waits until every image of the job queued so far is flushed to the
+// repository, the images that other jobs keep queueing meanwhile don't delay it
+func (s Service) FlushJob(ctx context.Context, jobID int64) error {
+	return s.waitFlushed(ctx, func() int64 { return s.pending.job(jobID) })
+}
+
+// waitFlushed flushes the partial batch and waits until pending is zero
+func (s Service) waitFlushed(ctx context.Context, pending func() int64) error {
+	//the partial batch is flushed right away instead of after FlushInterval
+	select {
+	case s.flushNow <- struct{}{}:
+	default:
+	}
+	t := time.NewTicker(flushPollInterval)
+	defer t.Stop()
+	for pending() > 0 {
 		select {
-		case image, ok := <-s.createQueue:
-			if !ok {
-				return
-			}
-			imageBatch = append(imageBatch, image)
 		case <-t.C:
-			if len(imageBatch) > 0 {
-				err := s.imageRepo.CreateBatch(context.Background(), imageBatch)
-				if err != nil {
-					s.logger.Error(err)
-				}
-				imageBatch = make([]*entity.Image, 0)
-			}
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+	return nil
+}
+
+// Close stops the batch writer after it flushed every queued image, it
+// returns ctx.Err() if ctx is done first. Create must not be called afterwards
+func (s Service) Close(ctx context.Context) error {
+	close(s.createQueue)
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
+// LoadHashIndex adds the hashes of every stored image to index so the
+// downloader can skip images that are already in the database
+func (s Service) LoadHashIndex(ctx context.Context, index *image.HashIndex) error {
+	hashes, err := s.imageRepo.ListHashes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		index.Add(hash.PixelHash, hash.PerceptualHash)
+	}
+	return nil
 }
 
-func (s Service) Create(downloader image.Downloader, done chan bool) {
-	filePathsChan := make(chan string, 100)
-	go downloader.Download(filePathsChan)
+// Create downloads images until the downloader is finished or ctx is cancelled,
+// done is signalled once every downloaded image is queued, use Flush to wait for them to be stored
+func (s Service) Create(ctx context.Context, downloader image.Downloader, done chan bool) {
+	resultChan := make(chan image.DownloadResult, 100)
+	go downloader.Download(ctx, resultChan)
 	go func() {
-		for path := range filePathsChan {
-			s.createQueue <- &entity.Image{
-				File: path,
-			}
+		for result := range resultChan {
+			s.enqueue(&entity.Image{
+				File:           result.File,
+				StorageBackend: result.StorageBackend,
+				SourceURL:      result.SourceURL,
+				SearchEngine:   result.SearchEngine,
+				Query:          result.Query,
+				OriginalWidth:  result.OriginalWidth,
+				OriginalHeight: result.OriginalHeight,
+				Width:          result.Width,
+				Height:         result.Height,
+				ByteSize:       result.ByteSize,
+				ContentType:    result.ContentType,
+				SHA256:         result.SHA256,
+				PixelHash:      result.PixelHash,
+				PerceptualHash: result.PerceptualHash,
+				JobID:          result.JobID,
+				Variants:       toImageVariants(result.Variants),
+				CreatedAt:      result.CreatedAt,
+			})
 		}
 		done <- true
 	}()
 }
 
-// reading in chunks
-func (s Service) Read(targetCount uint64, ch chan *entity.Image) {
+func toImageVariants(results []image.VariantResult) []entity.ImageVariant {
+	variants := make([]entity.ImageVariant, 0, len(results))
+	for _, v := range results {
+		variants = append(variants, entity.ImageVariant{
+			Name:        v.Name,
+			File:        v.File,
+			Width:       v.Width,
+			Height:      v.Height,
+			ByteSize:    v.ByteSize,
+			ContentType: v.ContentType,
+			SHA256:      v.SHA256,
+		})
+	}
+	return variants
+}
+
+// ReadMode decides what Read does once it reaches the last image
+type ReadMode string
+
+const (
+	// ReadStopAtEnd stops at the last image, fewer images than asked for may be read
+	ReadStopAtEnd ReadMode = "stop-at-end"
+	// ReadCircular starts over from the first image until enough images are read,
+	// images are repeated when the table holds fewer of them
+	ReadCircular ReadMode = "circular"
+)
+
+func (m ReadMode) Validate() error {
+	switch m {
+	case ReadStopAtEnd, ReadCircular:
+		return nil
+	}
+	return fmt.Errorf("%w %q, use %s or %s", ErrInvalidReadMode, string(m), ReadStopAtEnd, ReadCircular)
+}
+
+// Read sends up to count images to ch in id order and closes ch when it
+// returns. It returns ErrNoImages when the table is empty and ctx.Err() when
+// ctx is done before count images were read
+func (s Service) Read(ctx context.Context, count uint64, mode ReadMode, ch chan<- *entity.Image) error {
 	defer close(ch)
-	var count, offset uint64
-	for {
-		ctx, _ := context.WithTimeout(context.Background(), time.Millisecond*100)
-		images, err := s.imageRepo.List(ctx, offset)
-		if err != nil {
-			s.logger.Error(err)
-		}
-		if len(images) < 1 {
-			offset = 0 //circular reading...
-		}
-		for _, img := range images {
-			ch <- img
-			count++
-			if count >= targetCount {
-				return
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	var read uint64
+	var afterID int64
+	for read < count {
+		passStart := read
+		err := s.imageRepo.Stream(ctx, afterID, func(img *entity.Image) error {
+			select {
+			case ch <- img:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			afterID = img.ID
+			read++
+			if read >= count {
+				return errReadDone
 			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errReadDone):
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			return err
+		case read == 0:
+			return ErrNoImages
+		case mode == ReadStopAtEnd:
+			return nil
+		case afterID == 0 && read == passStart:
+			//the images were deleted while reading
+			return nil
 		}
-		offset += 10
+		afterID = 0 //circular reading...
+	}
+	return nil
+}
+
+// List returns up to limit images with an id greater than afterID ordered by
+// id, the id of the last image is the afterID of the next page. The limit is
+// capped at MaxListLimit
+func (s Service) List(ctx context.Context, afterID int64, limit int) ([]*entity.Image, error) {
+	return s.imageRepo.List(ctx, afterID, min(limit, MaxListLimit))
+}
+
+// OpenFile returns the image with id and the content of its main file, the
+// caller closes it. It returns imageRepo.ErrNotFound for unknown ids
+func (s Service) OpenFile(ctx context.Context, id int64) (*entity.Image, io.ReadCloser, error) {
+	img, err := s.imageRepo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if img.StorageBackend != s.storage.Name() {
+		return nil, nil, fmt.Errorf("%w %s", ErrOtherBackend, img.StorageBackend)
+	}
+	r, err := s.storage.Get(ctx, img.File)
+	if err != nil {
+		return nil, nil, err
 	}
+	return img, r, nil
 }
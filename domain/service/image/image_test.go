@@ -5,10 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
-	"m1-article-service/domain/entity"
-	mock_log "m1-article-service/mock/infrastructure"
-	mock_image "m1-article-service/mock/repository"
-	mock_utils "m1-article-service/mock/utils"
+	"scrapper/domain/entity"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	mock_utils "scrapper/mock/utils"
+	"scrapper/utils/image"
 	"testing"
 	"time"
 )
@@ -37,14 +38,15 @@ func TestService_Create(t *testing.T) {
 			},
 			ImageRepoMock: func() *mock_image.MockImage {
 				repoLogMock := mock_image.NewMockImage(ctrl)
-				repoLogMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(downloadCount).Return(nil)
+				//the images arrive together so the writer stores them in a single batch
+				repoLogMock.EXPECT().CreateBatch(gomock.Any(), gomock.Len(downloadCount)).Times(1).Return(nil)
 				return repoLogMock
 			},
 			downloaderMock: func() *mock_utils.MockDownloader {
 				downloaderMock := mock_utils.NewMockDownloader(ctrl)
-				downloaderMock.EXPECT().Download(gomock.Any()).Do(func(ch chan string) {
+				downloaderMock.EXPECT().Download(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, ch chan image.DownloadResult) {
 					for i := 0; i < downloadCount; i++ {
-						ch <- fmt.Sprintf("%d", i)
+						ch <- image.DownloadResult{File: fmt.Sprintf("%d", i)}
 					}
 					close(ch)
 				}).MaxTimes(1)
@@ -56,7 +58,7 @@ func TestService_Create(t *testing.T) {
 			name: "RepoError",
 			loggerMock: func() *mock_log.MockLog {
 				loggerInfra := mock_log.NewMockLog(ctrl)
-				loggerInfra.EXPECT().Error(err).MinTimes(10).Return()
+				loggerInfra.EXPECT().Error(err).MinTimes(1).Return()
 				return loggerInfra
 			},
 			ImageRepoMock: func() *mock_image.MockImage {
@@ -66,9 +68,9 @@ func TestService_Create(t *testing.T) {
 			},
 			downloaderMock: func() *mock_utils.MockDownloader {
 				downloaderMock := mock_utils.NewMockDownloader(ctrl)
-				downloaderMock.EXPECT().Download(gomock.Any()).Do(func(ch chan string) {
+				downloaderMock.EXPECT().Download(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, ch chan image.DownloadResult) {
 					for i := 0; i < downloadCount; i++ {
-						ch <- fmt.Sprintf("%d", i)
+						ch <- image.DownloadResult{File: fmt.Sprintf("%d", i)}
 					}
 					close(ch)
 				}).MaxTimes(1)
@@ -84,9 +86,9 @@ func TestService_Create(t *testing.T) {
 			loggerMock := test.loggerMock()
 			downloaderMock := test.downloaderMock()
 
-			service := NewService(loggerMock, logRepoMock, "")
+			service := NewService(loggerMock, logRepoMock, nil, WriterConfig{})
 			ch := make(chan bool)
-			service.Create(downloaderMock, ch)
+			service.Create(test.ctx, downloaderMock, ch)
 			<-ch
 			time.Sleep(2 * time.Second)
 			loggerMock.EXPECT()
@@ -99,21 +101,21 @@ func BenchmarkService_Create(b *testing.B) {
 	ctrl := gomock.NewController(b)
 	downloadCount := 100
 	downloaderMock := mock_utils.NewMockDownloader(ctrl)
-	downloaderMock.EXPECT().Download(gomock.Any()).Do(func(ch chan string) {
+	downloaderMock.EXPECT().Download(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, ch chan image.DownloadResult) {
 		for i := 0; i < downloadCount; i++ {
-			ch <- fmt.Sprintf("%d", i)
+			ch <- image.DownloadResult{File: fmt.Sprintf("%d", i)}
 		}
 		close(ch)
 	}).MaxTimes(1)
 
 	repoImageMock := mock_image.NewMockImage(ctrl)
-	repoImageMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(downloadCount).Return(nil)
+	repoImageMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).MinTimes(1).Return(nil)
 
 	loggerMock := mock_log.NewMockLog(ctrl)
 	b.ResetTimer()
-	service := NewService(loggerMock, repoImageMock, "/")
+	service := NewService(loggerMock, repoImageMock, nil, WriterConfig{})
 	done := make(chan bool)
-	service.Create(downloaderMock, done)
+	service.Create(context.Background(), downloaderMock, done)
 	<-done
 	fmt.Println("create method:", b.Elapsed())
 	if b.Elapsed() > 10*time.Millisecond {
@@ -132,57 +134,77 @@ func TestService_Read(t *testing.T) {
 	err := errors.New("error")
 
 	var tests = []struct {
-		name           string
-		Image          *entity.Image
-		loggerMock     func() *mock_log.MockLog
-		ImageRepoMock  func() *mock_image.MockImage
-		downloaderMock func() *mock_utils.MockDownloader
-		error          error
-		ctx            context.Context
-		count          uint64
-		mustDoneCount  uint64
+		name          string
+		ImageRepoMock func() *mock_image.MockImage
+		mode          ReadMode
+		error         error
+		count         uint64
+		mustDoneCount uint64
 	}{
 		{
 			name: "success",
-			loggerMock: func() *mock_log.MockLog {
-				loggerInfra := mock_log.NewMockLog(ctrl)
-				return loggerInfra
-			},
 			ImageRepoMock: func() *mock_image.MockImage {
 				repoLogMock := mock_image.NewMockImage(ctrl)
-				repoLogMock.EXPECT().List(gomock.Any(), gomock.Any()).AnyTimes().Return([]*entity.Image{
-					{"1"},
-					{"2"},
-					{"3"},
-					{"4"},
-					{"5"},
-					{"6"},
-					{"7"},
-					{"8"},
-					{"9"},
-					{"10"},
-				}, nil)
+				repoLogMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(streamImages(10))
 				return repoLogMock
 			},
-			error:         nil,
-			ctx:           context.Background(),
+			mode:          ReadStopAtEnd,
 			count:         10,
 			mustDoneCount: 10,
 		},
 		{
-			name: "RepoError",
-			loggerMock: func() *mock_log.MockLog {
-				loggerInfra := mock_log.NewMockLog(ctrl)
-				loggerInfra.EXPECT().Error(err).MinTimes(100).Return()
-				return loggerInfra
+			name: "stop at end",
+			ImageRepoMock: func() *mock_image.MockImage {
+				repoLogMock := mock_image.NewMockImage(ctrl)
+				repoLogMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(streamImages(10))
+				return repoLogMock
 			},
+			mode:          ReadStopAtEnd,
+			count:         25,
+			mustDoneCount: 10,
+		},
+		{
+			name: "circular",
 			ImageRepoMock: func() *mock_image.MockImage {
 				repoLogMock := mock_image.NewMockImage(ctrl)
-				repoLogMock.EXPECT().List(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, err)
+				repoLogMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(streamImages(10))
 				return repoLogMock
 			},
+			mode:          ReadCircular,
+			count:         25,
+			mustDoneCount: 25,
+		},
+		{
+			name: "EmptyTable",
+			ImageRepoMock: func() *mock_image.MockImage {
+				repoLogMock := mock_image.NewMockImage(ctrl)
+				repoLogMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(streamImages(0))
+				return repoLogMock
+			},
+			mode:          ReadCircular,
+			error:         ErrNoImages,
+			count:         10,
+			mustDoneCount: 0,
+		},
+		{
+			name: "RepoError",
+			ImageRepoMock: func() *mock_image.MockImage {
+				repoLogMock := mock_image.NewMockImage(ctrl)
+				repoLogMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(err)
+				return repoLogMock
+			},
+			mode:          ReadCircular,
 			error:         err,
-			ctx:           context.Background(),
+			count:         uint64(10),
+			mustDoneCount: uint64(0),
+		},
+		{
+			name: "InvalidMode",
+			ImageRepoMock: func() *mock_image.MockImage {
+				return mock_image.NewMockImage(ctrl)
+			},
+			mode:          ReadMode("sideways"),
+			error:         ErrInvalidReadMode,
 			count:         uint64(10),
 			mustDoneCount: uint64(0),
 		},
@@ -191,65 +213,75 @@ func TestService_Read(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			imageRepoMock := test.ImageRepoMock()
-			loggerMock := test.loggerMock()
+			loggerMock := mock_log.NewMockLog(ctrl)
 
-			service := NewService(loggerMock, imageRepoMock, "")
+			service := NewService(loggerMock, imageRepoMock, nil, WriterConfig{})
 			images := make(chan *entity.Image, 10)
-			go service.Read(test.count, images)
-			var count uint64
 			//considering a deadline in case of errors in repository
-			tick := time.NewTicker(time.Second * 2)
-
-			done := false
-			for !done {
-				select {
-				case _, ok := <-images:
-					if !ok {
-						done = true
-						break
-					}
-					count++
-				case <-tick.C:
-					done = true
-				}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- service.Read(ctx, test.count, test.mode, images)
+			}()
+			var count uint64
+			for range images {
+				count++
+			}
+			if err := <-errCh; !errors.Is(err, test.error) {
+				t.Errorf("error:%v is not:%v", err, test.error)
 			}
 			if count != test.mustDoneCount {
 				t.Errorf("count:%d is not equal to:%d", count, test.mustDoneCount)
 			}
-			loggerMock.EXPECT()
-			imageRepoMock.EXPECT()
 		})
 	}
 }
 
+func TestService_ReadCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	imageRepoMock := mock_image.NewMockImage(ctrl)
+	imageRepoMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(streamImages(10))
+	service := NewService(mock_log.NewMockLog(ctrl), imageRepoMock, nil, WriterConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	images := make(chan *entity.Image)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- service.Read(ctx, 100, ReadCircular, images)
+	}()
+	<-images
+	//nobody reads anymore, Read must return instead of blocking on the channel
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error:%v is not context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Read didn't return after ctx was cancelled")
+	}
+}
+
 func BenchmarkService_Read(b *testing.B) {
 	ctrl := gomock.NewController(b)
 
 	repoImageMock := mock_image.NewMockImage(ctrl)
-	repoImageMock.EXPECT().List(gomock.Any(), gomock.Any()).
-		AnyTimes().Return([]*entity.Image{
-		{"1"},
-		{"2"},
-		{"3"},
-		{"4"},
-		{"5"},
-		{"6"},
-		{"7"},
-		{"8"},
-		{"9"},
-		{"10"},
-	}, nil)
+	repoImageMock.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().DoAndReturn(streamImages(10))
 
 	loggerMock := mock_log.NewMockLog(ctrl)
 	b.ResetTimer()
-	service := NewService(loggerMock, repoImageMock, "")
+	service := NewService(loggerMock, repoImageMock, nil, WriterConfig{})
 	images := make(chan *entity.Image, 10)
 	mustDoneCount := uint64(1000)
-	go service.Read(mustDoneCount, images)
+	go service.Read(context.Background(), mustDoneCount, ReadCircular, images)
 
 	var count uint64
-	for image := range images {
-		image = image //aviod unused error
+	for range images {
 		count++
 	}
 
@@ -264,3 +296,15 @@ func BenchmarkService_Read(b *testing.B) {
 	loggerMock.EXPECT()
 	repoImageMock.EXPECT()
 }
+
+// streamImages returns a Stream implementation serving a table of n images
+func streamImages(n int64) func(context.Context, int64, func(*entity.Image) error) error {
+	return func(ctx context.Context, afterID int64, fn func(*entity.Image) error) error {
+		for id := afterID + 1; id <= n; id++ {
+			if err := fn(&entity.Image{ID: id, File: fmt.Sprintf("%d", id)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
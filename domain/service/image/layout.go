@@ -0,0 +1,96 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"scrapper/utils/image"
+)
+
+// LayoutMigration counts the files handled by MigrateLayout
+type LayoutMigration struct {
+	Moved     int
+	Unchanged int
+	Failed    int
+}
+
+func (m LayoutMigration) String() string {
+	return fmt.Sprintf("moved %d, unchanged %d, failed %d", m.Moved, m.Unchanged, m.Failed)
+}
+
+// MigrateLayout moves every stored file to the key layout gives its content
+// and rewrites the file column of its rows. A file is copied, then its rows
+// are updated and only then the old key is deleted, so an interrupted
+// migration never loses a file and can simply be run again.
+// With dryRun the files are hashed and counted without moving anything
+func (s Service) MigrateLayout(ctx context.Context, layout image.Layout, dryRun bool) (LayoutMigration, error) {
+	var m LayoutMigration
+	//the rows are collected first so the query isn't held open while the files are copied
+	files := make([]string, 0)
+	err := s.imageRepo.StreamFiles(ctx, s.storage.Name(), func(file string) error {
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return m, err
+		}
+		moved, err := s.migrateFile(ctx, layout, file, dryRun)
+		switch {
+		case err != nil:
+			m.Failed++
+			s.logger.Error(fmt.Errorf("migrating %s: %w", file, err))
+		case moved:
+			m.Moved++
+		default:
+			m.Unchanged++
+		}
+	}
+	return m, nil
+}
+
+func (s Service) migrateFile(ctx context.Context, layout image.Layout, file string, dryRun bool) (bool, error) {
+	r, err := s.storage.Get(ctx, file)
+	if err != nil {
+		return false, err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+	key := layout.Key(hex.EncodeToString(sum[:]), path.Ext(file))
+	if key == file {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	//identical files share one content addressed key, it may already be stored
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err = s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), http.DetectContentType(data)); err != nil {
+			return false, err
+		}
+	}
+	if err = s.imageRepo.RenameFile(ctx, file, key); err != nil {
+		return false, err
+	}
+	if err = s.storage.Delete(ctx, file); err != nil {
+		s.logger.Warning(fmt.Sprintf("deleting migrated file %s: %s", file, err))
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package image
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/golang/mock/gomock"
+	"os"
+	"path/filepath"
+	"scrapper/infrastructure/storage/filesystem"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"scrapper/utils/image"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// layoutRows is the file column of the stored rows, the repo mock reads and renames it
+type layoutRows struct {
+	files   []string
+	renamed int
+}
+
+func (r *layoutRows) expect(repoMock *mock_image.MockImage) {
+	repoMock.EXPECT().StreamFiles(gomock.Any(), filesystem.Name, gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, backend string, fn func(string) error) error {
+			for _, file := range r.files {
+				if err := fn(file); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	repoMock.EXPECT().RenameFile(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, oldFile, newFile string) error {
+			for i := range r.files {
+				if r.files[i] == oldFile {
+					r.files[i] = newFile
+				}
+			}
+			r.renamed++
+			return nil
+		})
+}
+
+func storedFiles(t *testing.T, root string) []string {
+	files := make([]string, 0)
+	err := filepath.WalkDir(root, func(p string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestService_MigrateLayout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	root := t.TempDir()
+	//dup.jpg has the content of a.jpg, both end up at the same key
+	contents := map[string]string{"a.jpg": "first image", "b.png": "second image", "dup.jpg": "first image"}
+	for name, content := range contents {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layout := image.DefaultLayout()
+	keyOf := func(content, ext string) string {
+		sum := sha256.Sum256([]byte(content))
+		return layout.Key(hex.EncodeToString(sum[:]), ext)
+	}
+	firstKey, secondKey := keyOf("first image", ".jpg"), keyOf("second image", ".png")
+
+	st, err := filesystem.NewStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := &layoutRows{files: []string{"a.jpg", "b.png", "dup.jpg"}}
+	repoMock := mock_image.NewMockImage(ctrl)
+	rows.expect(repoMock)
+	service := NewService(mock_log.NewMockLog(ctrl), repoMock, st, WriterConfig{})
+	ctx := context.Background()
+
+	//a dry run moves nothing
+	m, err := service.MigrateLayout(ctx, layout, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Moved != 3 || rows.renamed != 0 || len(storedFiles(t, root)) != 3 {
+		t.Fatalf("dry run %s renamed %d rows and left %v, must move nothing", m, rows.renamed, storedFiles(t, root))
+	}
+
+	m, err = service.MigrateLayout(ctx, layout, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Moved != 3 || m.Unchanged != 0 || m.Failed != 0 {
+		t.Errorf("migration %s, must move 3", m)
+	}
+	mustFiles := []string{firstKey, secondKey}
+	sort.Strings(mustFiles)
+	if files := storedFiles(t, root); strings.Join(files, ",") != strings.Join(mustFiles, ",") {
+		t.Errorf("stored files are %v, must be %v", files, mustFiles)
+	}
+	mustRows := []string{firstKey, secondKey, firstKey}
+	if strings.Join(rows.files, ",") != strings.Join(mustRows, ",") {
+		t.Errorf("rows are %v, must be %v", rows.files, mustRows)
+	}
+
+	//every file is at its key already
+	renamed := rows.renamed
+	m, err = service.MigrateLayout(ctx, layout, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Moved != 0 || m.Unchanged != 3 || rows.renamed != renamed {
+		t.Errorf("second migration %s renamed %d rows, must leave every file unchanged", m, rows.renamed-renamed)
+	}
+}
@@ -0,0 +1,283 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"scrapper/domain/entity"
+	imageRepo "scrapper/domain/repository/image"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize      = 1000
+	defaultFlushInterval  = 500 * time.Millisecond
+	defaultMaxConcurrency = 4
+	defaultMaxRetries     = 5
+	defaultRetryBackoff   = 100 * time.Millisecond
+	maxRetryBackoff       = 10 * time.Second
+)
+
+// WriterConfig configures the batch writer storing the created images, zero
+// fields get their default
+type WriterConfig struct {
+	// BatchSize is the number of queued images that triggers a flush
+	BatchSize int
+	// FlushInterval is the longest time an image waits in a partial batch
+	FlushInterval time.Duration
+	// MaxConcurrency is the number of batches written at the same time, keep
+	// it below the connection pool size so reads still get connections
+	MaxConcurrency int
+	// MaxRetries is the number of times a batch failing with a transient
+	// error is retried, negative disables retries
+	MaxRetries int
+	// RetryBackoff is the longest wait before the first retry, it doubles with every retry
+	RetryBackoff time.Duration
+	// DeadLetterPath is the JSON lines file receiving the batches that still
+	// fail, they are only logged when it is empty
+	DeadLetterPath string
+}
+
+func (c WriterConfig) withDefaults() WriterConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultFlushInterval
+	}
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = defaultMaxConcurrency
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaultRetryBackoff
+	}
+	return c
+}
+
+// FlushStats are the counters of the batch writer
+type FlushStats struct {
+	Flushes          uint64 //batches written
+	SizeFlushes      uint64 //batches flushed because they were full
+	TimerFlushes     uint64 //batches flushed by FlushInterval or Flush
+	Rows             uint64 //images written, duplicates included
+	DuplicateFlushes uint64 //batches in which some images were already stored
+	FailedRows       uint64 //images of batches that failed
+	DeadLettered     uint64 //failed images written to the dead letter file
+	Retries          uint64
+	MaxDuration      time.Duration
+	TotalTime        time.Duration
+}
+
+func (s FlushStats) String() string {
+	var avg time.Duration
+	if s.Flushes > 0 {
+		avg = s.TotalTime / time.Duration(s.Flushes)
+	}
+	return fmt.Sprintf("%d flushes (%d full, %d timed, %d with duplicates), %d rows, %d failed rows (%d dead lettered), %d retries, avg %s, max %s",
+		s.Flushes, s.SizeFlushes, s.TimerFlushes, s.DuplicateFlushes, s.Rows, s.FailedRows, s.DeadLettered, s.Retries, avg, s.MaxDuration)
+}
+
+type flushStats struct {
+	mtx   sync.Mutex
+	stats FlushStats
+}
+
+func (f *flushStats) update(fn func(*FlushStats)) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	fn(&f.stats)
+}
+
+func (f *flushStats) snapshot() FlushStats {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.stats
+}
+
+// pendingImages counts the images queued but not flushed yet, in total and
+// for every job, so a job can wait for its own images only
+type pendingImages struct {
+	mtx   sync.Mutex
+	count int64
+	jobs  map[int64]int64
+}
+
+func (p *pendingImages) add(jobID int64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.count++
+	p.jobs[jobID]++
+}
+
+func (p *pendingImages) done(images []*entity.Image) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, image := range images {
+		p.count--
+		if p.jobs[image.JobID]--; p.jobs[image.JobID] <= 0 {
+			delete(p.jobs, image.JobID)
+		}
+	}
+}
+
+func (p *pendingImages) total() int64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.count
+}
+
+func (p *pendingImages) job(jobID int64) int64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.jobs[jobID]
+}
+
+// enqueue hands image to the batch writer, it blocks while the queue is full
+func (s Service) enqueue(image *entity.Image) {
+	s.pending.add(image.JobID)
+	s.createQueue <- image
+}
+
+// runWriter collects the queued images into batches and flushes a batch once
+// it holds BatchSize images or its oldest image waited FlushInterval. At most
+// MaxConcurrency batches are written at the same time, the writer blocks
+// beyond that so the queue applies back pressure to Create
+func (s Service) runWriter() {
+	defer s.workers.Done()
+	inFlight := &sync.WaitGroup{}
+	defer inFlight.Wait()
+	slots := make(chan struct{}, s.writerCfg.MaxConcurrency)
+
+	batch := make([]*entity.Image, 0, s.writerCfg.BatchSize)
+	timer := time.NewTimer(s.writerCfg.FlushInterval)
+	timer.Stop()
+	defer timer.Stop()
+
+	dispatch := func(full bool) {
+		if len(batch) == 0 {
+			return
+		}
+		if !timer.Stop() {
+			//drop a tick that fired meanwhile so it doesn't flush the next batch early
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		slots <- struct{}{}
+		inFlight.Add(1)
+		go func(batch []*entity.Image) {
+			defer inFlight.Done()
+			defer func() { <-slots }()
+			s.flush(batch, full)
+		}(batch)
+		batch = make([]*entity.Image, 0, s.writerCfg.BatchSize)
+	}
+
+	for {
+		select {
+		case image, ok := <-s.createQueue:
+			if !ok {
+				//queue is closed on shutdown, flush what is left before exiting
+				dispatch(false)
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(s.writerCfg.FlushInterval)
+			}
+			batch = append(batch, image)
+			if len(batch) >= s.writerCfg.BatchSize {
+				dispatch(true)
+			}
+		case <-timer.C:
+			dispatch(false)
+		case <-s.flushNow:
+			dispatch(false)
+		}
+	}
+}
+
+func (s Service) flush(imageBatch []*entity.Image, full bool) {
+	start := time.Now()
+	retries, err := s.createBatch(context.Background(), imageBatch)
+	deadLettered := false
+	if errors.Is(err, imageRepo.ErrAlreadyExist) {
+		s.logger.Warning(err.Error())
+	} else if err != nil {
+		s.logger.Error(err)
+		deadLettered = s.deadLetterBatch(imageBatch)
+	}
+	elapsed := time.Since(start)
+	s.flushStats.update(func(fs *FlushStats) {
+		fs.Flushes++
+		if full {
+			fs.SizeFlushes++
+		} else {
+			fs.TimerFlushes++
+		}
+		switch {
+		case errors.Is(err, imageRepo.ErrAlreadyExist):
+			fs.Rows += uint64(len(imageBatch))
+			fs.DuplicateFlushes++
+		case err != nil:
+			fs.FailedRows += uint64(len(imageBatch))
+		default:
+			fs.Rows += uint64(len(imageBatch))
+		}
+		fs.Retries += uint64(retries)
+		if deadLettered {
+			fs.DeadLettered += uint64(len(imageBatch))
+		}
+		fs.TotalTime += elapsed
+		if elapsed > fs.MaxDuration {
+			fs.MaxDuration = elapsed
+		}
+	})
+	s.pending.done(imageBatch)
+}
+
+// deadLetterBatch keeps the rows of a failed batch so they can be replayed,
+// their files are stored already. It reports whether the rows were kept
+func (s Service) deadLetterBatch(images []*entity.Image) bool {
+	if s.deadLetter.path == "" {
+		return false
+	}
+	if err := s.deadLetter.write(images); err != nil {
+		s.logger.Error(fmt.Errorf("dead lettering %d images: %w", len(images), err))
+		return false
+	}
+	return true
+}
+
+// createBatch stores the images, retrying transient failures with
+// exponential backoff and full jitter. It returns the number of retries
+func (s Service) createBatch(ctx context.Context, images []*entity.Image) (int, error) {
+	backoff := s.writerCfg.RetryBackoff
+	for retries := 0; ; retries++ {
+		err := s.imageRepo.CreateBatch(ctx, images)
+		if err == nil || !errors.Is(err, imageRepo.ErrTransient) || retries >= s.writerCfg.MaxRetries {
+			return retries, err
+		}
+		wait := time.Duration(rand.Int63n(int64(backoff) + 1))
+		s.logger.Warning(fmt.Sprintf("storing %d images failed, retry %d/%d in %s: %s",
+			len(images), retries+1, s.writerCfg.MaxRetries, wait, err))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return retries, err
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// FlushStats returns the counters of the batch writer
+func (s Service) FlushStats() FlushStats {
+	return s.flushStats.snapshot()
+}
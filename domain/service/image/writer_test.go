@@ -0,0 +1,92 @@
+package image
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"scrapper/domain/entity"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_writer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	var rows atomic.Int64
+	repoMock := mock_image.NewMockImage(ctrl)
+	repoMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(ctx context.Context, images []*entity.Image) error {
+			rows.Add(int64(len(images)))
+			return nil
+		})
+
+	service := NewService(mock_log.NewMockLog(ctrl), repoMock, nil, WriterConfig{
+		BatchSize:      4,
+		FlushInterval:  50 * time.Millisecond,
+		MaxConcurrency: 2,
+	})
+	for i := 0; i < 10; i++ {
+		service.enqueue(&entity.Image{})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	//two full batches are flushed right away, the last two images wait for the flush interval
+	time.Sleep(100 * time.Millisecond)
+	if err := service.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := service.FlushStats()
+	if rows.Load() != 10 || stats.Rows != 10 {
+		t.Errorf("wrote %d rows, stats count %d, must be 10", rows.Load(), stats.Rows)
+	}
+	if stats.Flushes != 3 || stats.SizeFlushes != 2 || stats.TimerFlushes != 1 {
+		t.Errorf("stats are %+v, must have 3 flushes, 2 of them full", stats)
+	}
+}
+
+func TestService_FlushJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	release := make(chan struct{})
+	repoMock := mock_image.NewMockImage(ctrl)
+	repoMock.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, images []*entity.Image) error {
+			//the images of job 2 are still being written
+			if images[0].JobID == 2 {
+				<-release
+			}
+			return nil
+		})
+
+	service := NewService(mock_log.NewMockLog(ctrl), repoMock, nil, WriterConfig{BatchSize: 1, MaxConcurrency: 2})
+	service.enqueue(&entity.Image{JobID: 2})
+	service.enqueue(&entity.Image{JobID: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := service.FlushJob(ctx, 1); err != nil {
+		t.Fatalf("error is %v, job 1 must not wait for the images of job 2", err)
+	}
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer shortCancel()
+	if err := service.Flush(shortCtx); err == nil {
+		t.Error("flush must wait for the images of job 2")
+	}
+	close(release)
+	if err := service.FlushJob(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"scrapper/domain/entity"
+	jobRepo "scrapper/domain/repository/job"
+	logger "scrapper/infrastructure/log"
+	"time"
+)
+
+const (
+	seenURLBatchSize     = 1000
+	seenURLQueueLength   = 20000
+	seenURLFlushInterval = time.Second
+)
+
+// ErrInvalidRequest is wrapped by the errors of requests that can't start a job
+var ErrInvalidRequest = errors.New("invalid job request")
+
+// DefaultQueryWeight is the weight of a requested query without one
+const DefaultQueryWeight = 1
+
+// Request describes a create job started through the API
+type Request struct {
+	Count   uint64            `json:"count"`
+	Queries []entity.JobQuery `json:"queries"` //the default queries are used when empty
+	Engines []string          `json:"engines"` //every search engine is used when empty
+	Proxy   bool              `json:"proxy"`
+}
+
+// WithDefaults returns the request with DefaultQueryWeight for the queries
+// that left their weight unset
+func (r Request) WithDefaults() Request {
+	if len(r.Queries) == 0 {
+		return r
+	}
+	queries := make([]entity.JobQuery, len(r.Queries))
+	copy(queries, r.Queries)
+	for i := range queries {
+		if queries[i].Weight == 0 {
+			queries[i].Weight = DefaultQueryWeight
+		}
+	}
+	r.Queries = queries
+	return r
+}
+
+type Service struct {
+	jobRepo jobRepo.Job
+	logger  logger.Logger
+}
+
+func NewService(logger logger.Logger, jobRepo jobRepo.Job) *Service {
+	return &Service{
+		jobRepo: jobRepo,
+		logger:  logger,
+	}
+}
+
+func (s Service) Start(ctx context.Context, targetCount uint64, queries []entity.JobQuery, engines []string) (*entity.Job, error) {
+	job := &entity.Job{
+		TargetCount: targetCount,
+		Queries:     queries,
+		Engines:     engines,
+		Status:      entity.JobStatusRunning,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s Service) Get(ctx context.Context, id int64) (*entity.Job, error) {
+	return s.jobRepo.Get(ctx, id)
+}
+
+// Progress returns the job with the number of images saved so far, the saved
+// count kept in the job itself is only refreshed when a run stops
+func (s Service) Progress(ctx context.Context, id int64) (*entity.Job, error) {
+	job, err := s.jobRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == entity.JobStatusRunning {
+		savedCount, err := s.jobRepo.CountImages(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		job.SavedCount = savedCount
+	}
+	return job, nil
+}
+
+// ResumeState returns what a resumed job needs to continue where it stopped,
+// the number of images already saved and the urls it already tried
+func (s Service) ResumeState(ctx context.Context, id int64) (uint64, []string, error) {
+	savedCount, err := s.jobRepo.CountImages(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	seen, err := s.jobRepo.ListSeenURLs(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.jobRepo.UpdateProgress(ctx, id, entity.JobStatusRunning); err != nil {
+		return 0, nil, err
+	}
+	return savedCount, seen, nil
+}
+
+// UpdateProgress refreshes the saved count of the job and sets its status
+func (s Service) UpdateProgress(ctx context.Context, id int64, status string) error {
+	return s.jobRepo.UpdateProgress(ctx, id, status)
+}
+
+// SeenURLRecorder persists the image urls tried by a job in batches
+type SeenURLRecorder struct {
+	urls chan string
+	done chan bool
+}
+
+func (s Service) NewSeenURLRecorder(jobID int64) *SeenURLRecorder {
+	r := &SeenURLRecorder{
+		urls: make(chan string, seenURLQueueLength),
+		done: make(chan bool),
+	}
+	go s.recordSeenURLs(jobID, r)
+	return r
+}
+
+func (r *SeenURLRecorder) Record(url string) {
+	r.urls <- url
+}
+
+// Close flushes the pending urls, Record must not be called afterwards
+func (r *SeenURLRecorder) Close() {
+	close(r.urls)
+	<-r.done
+}
+
+func (s Service) recordSeenURLs(jobID int64, r *SeenURLRecorder) {
+	batch := make([]string, 0, seenURLBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.jobRepo.AddSeenURLs(context.Background(), jobID, batch); err != nil {
+			s.logger.Error(err)
+		}
+		batch = make([]string, 0, seenURLBatchSize)
+	}
+
+	t := time.NewTicker(seenURLFlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case url, ok := <-r.urls:
+			if !ok {
+				flush()
+				r.done <- true
+				return
+			}
+			batch = append(batch, url)
+			if len(batch) >= seenURLBatchSize {
+				flush()
+			}
+		case <-t.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"scrapper/domain/entity"
+	mock_log "scrapper/mock/infrastructure"
+	mock_image "scrapper/mock/repository"
+	"sync"
+	"testing"
+)
+
+func TestService_ResumeState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	err := errors.New("error")
+	seen := []string{"https://a/1.jpg", "https://a/2.jpg"}
+
+	var tests = []struct {
+		name        string
+		jobRepoMock func() *mock_image.MockJob
+		mustSaved   uint64
+		mustSeen    []string
+		mustErr     error
+	}{
+		{
+			name: "success",
+			jobRepoMock: func() *mock_image.MockJob {
+				jobRepoMock := mock_image.NewMockJob(ctrl)
+				jobRepoMock.EXPECT().CountImages(gomock.Any(), int64(7)).Return(uint64(40), nil)
+				jobRepoMock.EXPECT().ListSeenURLs(gomock.Any(), int64(7)).Return(seen, nil)
+				jobRepoMock.EXPECT().UpdateProgress(gomock.Any(), int64(7), entity.JobStatusRunning).Return(nil)
+				return jobRepoMock
+			},
+			mustSaved: 40,
+			mustSeen:  seen,
+		},
+		{
+			name: "CountError",
+			jobRepoMock: func() *mock_image.MockJob {
+				jobRepoMock := mock_image.NewMockJob(ctrl)
+				jobRepoMock.EXPECT().CountImages(gomock.Any(), int64(7)).Return(uint64(0), err)
+				return jobRepoMock
+			},
+			mustErr: err,
+		},
+		{
+			name: "ListSeenURLsError",
+			jobRepoMock: func() *mock_image.MockJob {
+				jobRepoMock := mock_image.NewMockJob(ctrl)
+				jobRepoMock.EXPECT().CountImages(gomock.Any(), int64(7)).Return(uint64(40), nil)
+				jobRepoMock.EXPECT().ListSeenURLs(gomock.Any(), int64(7)).Return(nil, err)
+				return jobRepoMock
+			},
+			mustErr: err,
+		},
+		{
+			name: "UpdateProgressError",
+			jobRepoMock: func() *mock_image.MockJob {
+				jobRepoMock := mock_image.NewMockJob(ctrl)
+				jobRepoMock.EXPECT().CountImages(gomock.Any(), int64(7)).Return(uint64(40), nil)
+				jobRepoMock.EXPECT().ListSeenURLs(gomock.Any(), int64(7)).Return(seen, nil)
+				jobRepoMock.EXPECT().UpdateProgress(gomock.Any(), int64(7), entity.JobStatusRunning).Return(err)
+				return jobRepoMock
+			},
+			mustErr: err,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := NewService(mock_log.NewMockLog(ctrl), test.jobRepoMock())
+			saved, seenURLs, err := service.ResumeState(context.Background(), 7)
+			if !errors.Is(err, test.mustErr) {
+				t.Fatalf("error is %v, must be %v", err, test.mustErr)
+			}
+			if saved != test.mustSaved {
+				t.Errorf("saved count:%d is not equal to:%d", saved, test.mustSaved)
+			}
+			if len(seenURLs) != len(test.mustSeen) {
+				t.Errorf("seen urls:%v are not equal to:%v", seenURLs, test.mustSeen)
+			}
+		})
+	}
+}
+
+func TestService_Progress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+
+	var tests = []struct {
+		name           string
+		job            *entity.Job
+		countImages    bool
+		mustSavedCount uint64
+	}{
+		{
+			name:           "running job counts its images",
+			job:            &entity.Job{ID: 7, SavedCount: 10, Status: entity.JobStatusRunning},
+			countImages:    true,
+			mustSavedCount: 25,
+		},
+		{
+			name:           "stopped job keeps its saved count",
+			job:            &entity.Job{ID: 7, SavedCount: 10, Status: entity.JobStatusInterrupted},
+			mustSavedCount: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jobRepoMock := mock_image.NewMockJob(ctrl)
+			jobRepoMock.EXPECT().Get(gomock.Any(), int64(7)).Return(test.job, nil)
+			if test.countImages {
+				jobRepoMock.EXPECT().CountImages(gomock.Any(), int64(7)).Return(uint64(25), nil)
+			}
+			service := NewService(mock_log.NewMockLog(ctrl), jobRepoMock)
+			job, err := service.Progress(context.Background(), 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.SavedCount != test.mustSavedCount {
+				t.Errorf("saved count:%d is not equal to:%d", job.SavedCount, test.mustSavedCount)
+			}
+		})
+	}
+}
+
+func TestSeenURLRecorder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	urlCount := 2*seenURLBatchSize + 500
+	err := errors.New("error")
+
+	var tests = []struct {
+		name       string
+		addErr     error
+		loggerMock func() *mock_log.MockLog
+	}{
+		{
+			name: "success",
+			loggerMock: func() *mock_log.MockLog {
+				return mock_log.NewMockLog(ctrl)
+			},
+		},
+		{
+			name:   "AddSeenURLsError",
+			addErr: err,
+			loggerMock: func() *mock_log.MockLog {
+				loggerMock := mock_log.NewMockLog(ctrl)
+				loggerMock.EXPECT().Error(err).MinTimes(1)
+				return loggerMock
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mtx := &sync.Mutex{}
+			persisted := make([]string, 0, urlCount)
+			jobRepoMock := mock_image.NewMockJob(ctrl)
+			jobRepoMock.EXPECT().AddSeenURLs(gomock.Any(), int64(7), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, id int64, urls []string) error {
+					if len(urls) == 0 || len(urls) > seenURLBatchSize {
+						t.Errorf("batch of %d urls, must hold 1 to %d", len(urls), seenURLBatchSize)
+					}
+					mtx.Lock()
+					defer mtx.Unlock()
+					persisted = append(persisted, urls...)
+					return test.addErr
+				}).MinTimes(3)
+
+			service := NewService(test.loggerMock(), jobRepoMock)
+			recorder := service.NewSeenURLRecorder(7)
+			for i := 0; i < urlCount; i++ {
+				recorder.Record(fmt.Sprintf("https://a/%d.jpg", i))
+			}
+			//Close flushes the last partial batch
+			recorder.Close()
+
+			if len(persisted) != urlCount {
+				t.Fatalf("%d urls were persisted, must be %d", len(persisted), urlCount)
+			}
+			for i, u := range persisted {
+				if u != fmt.Sprintf("https://a/%d.jpg", i) {
+					t.Fatalf("url %d is %s, urls must be persisted in order", i, u)
+				}
+			}
+		})
+	}
+}
+
+func TestRequest_WithDefaults(t *testing.T) {
+	req := Request{Count: 1, Queries: []entity.JobQuery{{Text: "cat"}, {Text: "dog", Weight: 3}, {Text: "cow", Weight: -1}}}
+	got := req.WithDefaults()
+	must := []entity.JobQuery{{Text: "cat", Weight: DefaultQueryWeight}, {Text: "dog", Weight: 3}, {Text: "cow", Weight: -1}}
+	if fmt.Sprint(got.Queries) != fmt.Sprint(must) {
+		t.Errorf("queries are %v, must be %v", got.Queries, must)
+	}
+	if req.Queries[0].Weight != 0 {
+		t.Error("the queries of the request must not change")
+	}
+	if got := (Request{Count: 1}).WithDefaults(); got.Queries != nil {
+		t.Errorf("queries are %v, must stay empty for the default queries", got.Queries)
+	}
+}
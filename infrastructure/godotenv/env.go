@@ -4,11 +4,39 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Env struct {
 	DATABASE_HOST string
 	ServerAddr    string
+	// GRPC_ADDR is the address of the gRPC API, it is off when empty
+	GRPC_ADDR string
+
+	// STORAGE_BACKEND is filesystem (default) or s3
+	STORAGE_BACKEND string
+	// STORAGE_DIR is the directory of the filesystem backend, ./images by default
+	STORAGE_DIR   string
+	S3_ENDPOINT   string
+	S3_BUCKET     string
+	S3_ACCESS_KEY string
+	S3_SECRET_KEY string
+	S3_REGION     string
+	S3_USE_SSL    bool
+	S3_PREFIX     string
+	S3_PUBLIC_URL string
+
+	// WRITER_BATCH_SIZE is the number of images written in one batch
+	WRITER_BATCH_SIZE int
+	// WRITER_FLUSH_INTERVAL is the longest time an image waits for its batch, e.g. 500ms
+	WRITER_FLUSH_INTERVAL time.Duration
+	// WRITER_MAX_CONCURRENCY is the number of batches written at the same time
+	WRITER_MAX_CONCURRENCY int
+	// WRITER_MAX_RETRIES is the number of retries of batches failing with a transient error
+	WRITER_MAX_RETRIES int
+	// DEADLETTER_FILE receives the images of batches that still fail, ./deadletter.jsonl by default
+	DEADLETTER_FILE string
 }
 
 func NewEnv() *Env {
@@ -21,4 +49,48 @@ func (e *Env) Load() {
 	}
 	e.DATABASE_HOST = os.Getenv("DATABASE_HOST")
 	e.ServerAddr = os.Getenv("ServerAddr")
+	e.GRPC_ADDR = os.Getenv("GRPC_ADDR")
+
+	e.STORAGE_BACKEND = os.Getenv("STORAGE_BACKEND")
+	e.STORAGE_DIR = os.Getenv("STORAGE_DIR")
+	e.S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+	e.S3_BUCKET = os.Getenv("S3_BUCKET")
+	e.S3_ACCESS_KEY = os.Getenv("S3_ACCESS_KEY")
+	e.S3_SECRET_KEY = os.Getenv("S3_SECRET_KEY")
+	e.S3_REGION = os.Getenv("S3_REGION")
+	e.S3_USE_SSL = os.Getenv("S3_USE_SSL") == "true"
+	e.S3_PREFIX = os.Getenv("S3_PREFIX")
+	e.S3_PUBLIC_URL = os.Getenv("S3_PUBLIC_URL")
+
+	e.WRITER_BATCH_SIZE = intEnv("WRITER_BATCH_SIZE")
+	e.WRITER_FLUSH_INTERVAL = durationEnv("WRITER_FLUSH_INTERVAL")
+	e.WRITER_MAX_CONCURRENCY = intEnv("WRITER_MAX_CONCURRENCY")
+	e.WRITER_MAX_RETRIES = intEnv("WRITER_MAX_RETRIES")
+	e.DEADLETTER_FILE = os.Getenv("DEADLETTER_FILE")
+}
+
+// intEnv returns zero when the variable is empty
+func intEnv(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s: %s", key, err)
+	}
+	return n
+}
+
+// durationEnv returns zero when the variable is empty
+func durationEnv(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s: %s", key, err)
+	}
+	return d
 }
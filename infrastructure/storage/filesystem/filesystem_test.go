@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStorage_Put(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("not really a jpeg")
+	if err = s.Put(context.Background(), "ab/cd/1.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "ab", "cd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "1.jpg" {
+		t.Fatalf("directory holds %v, must only hold 1.jpg", entries)
+	}
+	r, err := s.Get(context.Background(), "ab/cd/1.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, data) {
+		t.Fatalf("read %q, must be %q", got, data)
+	}
+}
+
+func TestStorage_SweepTemp(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(root, "ab", ".1.jpg.123"+tempSuffix)
+	fresh := filepath.Join(root, ".2.jpg.456"+tempSuffix)
+	kept := filepath.Join(root, "ab", "3.jpg")
+	for _, p := range []string{old, fresh, kept} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.SweepTemp(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d files, must remove 1", removed)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old temp file must be removed, stat error is %v", err)
+	}
+	for _, p := range []string{fresh, kept} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s must be kept: %v", p, err)
+		}
+	}
+}
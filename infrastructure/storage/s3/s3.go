@@ -0,0 +1,133 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"scrapper/infrastructure/storage"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const Name = "s3"
+
+type Config struct {
+	// Endpoint is the host[:port] of the S3 compatible server, e.g. s3.amazonaws.com or localhost:9000
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Region skips the bucket location lookup when set
+	Region string
+	UseSSL bool
+	// Prefix is prepended to every key, e.g. "images/"
+	Prefix string
+	// PublicURL is the base of the urls returned by URL, the endpoint and bucket are used when empty
+	PublicURL string
+}
+
+// Storage keeps the files in a bucket of an S3 compatible server such as AWS S3 or MinIO
+type Storage struct {
+	client *minio.Client
+	cfg    Config
+}
+
+func NewStorage(cfg Config) (*Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage: endpoint and bucket are required")
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{client: client, cfg: cfg}, nil
+}
+
+func (s *Storage) Name() string {
+	return Name
+}
+
+func (s *Storage) object(key string) string {
+	return s.cfg.Prefix + key
+}
+
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.cfg.Bucket, s.object(key), r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.cfg.Bucket, s.object(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	//GetObject is lazy, stat it so missing keys are reported here and not on the first read
+	if _, err = obj.Stat(); err != nil {
+		obj.Close()
+		return nil, mapError(err)
+	}
+	return obj, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.cfg.Bucket, s.object(key), minio.RemoveObjectOptions{})
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.cfg.Bucket, s.object(key), minio.StatObjectOptions{})
+	if err != nil {
+		if err = mapError(err); errors.Is(err, storage.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Storage) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + s.object(key)
+	}
+	scheme := "http"
+	if s.cfg.UseSSL {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: s.cfg.Endpoint, Path: "/" + path.Join(s.cfg.Bucket, s.object(key))}
+	return u.String()
+}
+
+func (s *Storage) Walk(ctx context.Context, fn func(key string, modTime time.Time) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	//cancelling stops the listing goroutine when fn fails
+	defer cancel()
+	objects := s.client.ListObjects(ctx, s.cfg.Bucket, minio.ListObjectsOptions{Prefix: s.cfg.Prefix, Recursive: true})
+	for obj := range objects {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(strings.TrimPrefix(obj.Key, s.cfg.Prefix), obj.LastModified); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// mapError turns missing object errors into storage.ErrNotFound
+func mapError(err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" {
+		return storage.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"scrapper/infrastructure/storage"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal in memory stand-in of a MinIO server, it understands
+// path style object requests and ignores authentication
+type fakeS3 struct {
+	mtx     sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string][]byte), types: make(map[string]string)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err == nil && strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			data, err = decodeChunked(data)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.objects[key] = data
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message><Key>%s</Key><RequestId>1</RequestId></Error>`, key)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeChunked strips the chunk signatures of a streaming signed upload
+func decodeChunked(body []byte) ([]byte, error) {
+	var data []byte
+	for {
+		header, rest, ok := bytes.Cut(body, []byte("\r\n"))
+		if !ok {
+			return nil, errors.New("truncated chunk header")
+		}
+		sizeHex, _, _ := bytes.Cut(header, []byte(";"))
+		var size int
+		if _, err := fmt.Sscanf(string(sizeHex), "%x", &size); err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		if len(rest) < size+2 {
+			return nil, errors.New("truncated chunk")
+		}
+		data = append(data, rest[:size]...)
+		body = rest[size+2:]
+	}
+}
+
+func newTestStorage(t *testing.T) (*Storage, *fakeS3) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStorage(Config{
+		Endpoint:  u.Host,
+		Bucket:    "images",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+		Region:    "us-east-1",
+		Prefix:    "sco/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, fake
+}
+
+func TestStorage(t *testing.T) {
+	s, fake := newTestStorage(t)
+	ctx := context.Background()
+	data := []byte("not really a jpeg")
+
+	if err := s.Put(ctx, "ab/1.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if contentType := fake.types["images/sco/ab/1.jpg"]; contentType != "image/jpeg" {
+		t.Errorf("stored content type is %q, must be image/jpeg", contentType)
+	}
+
+	ok, err := s.Exists(ctx, "ab/1.jpg")
+	if err != nil || !ok {
+		t.Fatalf("exists is %t and error is %v, must exist", ok, err)
+	}
+	r, err := s.Get(ctx, "ab/1.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("read %q and error is %v, must read %q", read, err, data)
+	}
+
+	if err = s.Delete(ctx, "ab/1.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	ok, err = s.Exists(ctx, "ab/1.jpg")
+	if err != nil || ok {
+		t.Fatalf("exists is %t and error is %v after delete, must not exist", ok, err)
+	}
+	if _, err = s.Get(ctx, "ab/1.jpg"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("error is %v after delete, must be ErrNotFound", err)
+	}
+}
+
+func TestStorage_URL(t *testing.T) {
+	s := &Storage{cfg: Config{Endpoint: "localhost:9000", Bucket: "images", Prefix: "sco/"}}
+	if u, mustURL := s.URL("1.jpg"), "http://localhost:9000/images/sco/1.jpg"; u != mustURL {
+		t.Errorf("url is %q, must be %q", u, mustURL)
+	}
+	s.cfg.PublicURL = "https://cdn.example.com/"
+	if u, mustURL := s.URL("1.jpg"), "https://cdn.example.com/sco/1.jpg"; u != mustURL {
+		t.Errorf("url is %q, must be %q", u, mustURL)
+	}
+}
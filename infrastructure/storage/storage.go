@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps the downloaded image files, keys are slash separated relative paths
+type Storage interface {
+	// Name identifies the backend, it is stored with every image
+	Name() string
+	// Put stores size bytes of r under key, replacing any existing file. The
+	// file must be complete under key once Put returns nil and never partially written
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the content of key or ErrNotFound, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL returns where key can be fetched from
+	URL(key string) string
+	// Walk calls fn with the key and the last modification time of every stored
+	// file, an error returned by fn stops the walk and is returned
+	Walk(ctx context.Context, fn func(key string, modTime time.Time) error) error
+}
+
+// Sweeper is implemented by backends whose writes can leave temp files behind
+// when the process crashes
+type Sweeper interface {
+	// SweepTemp removes the temp files older than olderThan and returns how many were removed
+	SweepTemp(ctx context.Context, olderThan time.Duration) (int, error)
+}
@@ -7,7 +7,7 @@ package mock_image
 import (
 	context "context"
 	reflect "reflect"
-	"scrapper/domain/entity"
+	entity "scrapper/domain/entity"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -49,17 +49,103 @@ func (mr *MockImageMockRecorder) CreateBatch(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockImage)(nil).CreateBatch), arg0, arg1)
 }
 
+// DeleteFile mocks base method.
+func (m *MockImage) DeleteFile(ctx context.Context, file string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockImageMockRecorder) DeleteFile(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockImage)(nil).DeleteFile), ctx, file)
+}
+
+// Get mocks base method.
+func (m *MockImage) Get(ctx context.Context, id int64) (*entity.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*entity.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockImageMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockImage)(nil).Get), ctx, id)
+}
+
 // List mocks base method.
-func (m *MockImage) List(arg0 context.Context, arg1 uint64) ([]*entity.Image, error) {
+func (m *MockImage) List(ctx context.Context, afterID int64, limit int) ([]*entity.Image, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret := m.ctrl.Call(m, "List", ctx, afterID, limit)
 	ret0, _ := ret[0].([]*entity.Image)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockImageMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockImageMockRecorder) List(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockImage)(nil).List), ctx, afterID, limit)
+}
+
+// ListHashes mocks base method.
+func (m *MockImage) ListHashes(arg0 context.Context) ([]*entity.ImageHash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHashes", arg0)
+	ret0, _ := ret[0].([]*entity.ImageHash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHashes indicates an expected call of ListHashes.
+func (mr *MockImageMockRecorder) ListHashes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHashes", reflect.TypeOf((*MockImage)(nil).ListHashes), arg0)
+}
+
+// RenameFile mocks base method.
+func (m *MockImage) RenameFile(ctx context.Context, oldFile, newFile string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameFile", ctx, oldFile, newFile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameFile indicates an expected call of RenameFile.
+func (mr *MockImageMockRecorder) RenameFile(ctx, oldFile, newFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameFile", reflect.TypeOf((*MockImage)(nil).RenameFile), ctx, oldFile, newFile)
+}
+
+// Stream mocks base method.
+func (m *MockImage) Stream(ctx context.Context, afterID int64, fn func(*entity.Image) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", ctx, afterID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockImageMockRecorder) Stream(ctx, afterID, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockImage)(nil).Stream), ctx, afterID, fn)
+}
+
+// StreamFiles mocks base method.
+func (m *MockImage) StreamFiles(ctx context.Context, backend string, fn func(string) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamFiles", ctx, backend, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamFiles indicates an expected call of StreamFiles.
+func (mr *MockImageMockRecorder) StreamFiles(ctx, backend, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockImage)(nil).List), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamFiles", reflect.TypeOf((*MockImage)(nil).StreamFiles), ctx, backend, fn)
 }
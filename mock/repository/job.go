@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./domain/repository/job/job.go
+
+// Package mock_image is a generated GoMock package.
+package mock_image
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "scrapper/domain/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockJob is a mock of Job interface.
+type MockJob struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobMockRecorder
+}
+
+// MockJobMockRecorder is the mock recorder for MockJob.
+type MockJobMockRecorder struct {
+	mock *MockJob
+}
+
+// NewMockJob creates a new mock instance.
+func NewMockJob(ctrl *gomock.Controller) *MockJob {
+	mock := &MockJob{ctrl: ctrl}
+	mock.recorder = &MockJobMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJob) EXPECT() *MockJobMockRecorder {
+	return m.recorder
+}
+
+// AddSeenURLs mocks base method.
+func (m *MockJob) AddSeenURLs(ctx context.Context, id int64, urls []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSeenURLs", ctx, id, urls)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSeenURLs indicates an expected call of AddSeenURLs.
+func (mr *MockJobMockRecorder) AddSeenURLs(ctx, id, urls interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSeenURLs", reflect.TypeOf((*MockJob)(nil).AddSeenURLs), ctx, id, urls)
+}
+
+// CountImages mocks base method.
+func (m *MockJob) CountImages(arg0 context.Context, arg1 int64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountImages", arg0, arg1)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountImages indicates an expected call of CountImages.
+func (mr *MockJobMockRecorder) CountImages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountImages", reflect.TypeOf((*MockJob)(nil).CountImages), arg0, arg1)
+}
+
+// Create mocks base method.
+func (m *MockJob) Create(arg0 context.Context, arg1 *entity.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockJobMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockJob)(nil).Create), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockJob) Get(arg0 context.Context, arg1 int64) (*entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockJobMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockJob)(nil).Get), arg0, arg1)
+}
+
+// ListSeenURLs mocks base method.
+func (m *MockJob) ListSeenURLs(arg0 context.Context, arg1 int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSeenURLs", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSeenURLs indicates an expected call of ListSeenURLs.
+func (mr *MockJobMockRecorder) ListSeenURLs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSeenURLs", reflect.TypeOf((*MockJob)(nil).ListSeenURLs), arg0, arg1)
+}
+
+// UpdateProgress mocks base method.
+func (m *MockJob) UpdateProgress(ctx context.Context, id int64, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProgress", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProgress indicates an expected call of UpdateProgress.
+func (mr *MockJobMockRecorder) UpdateProgress(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProgress", reflect.TypeOf((*MockJob)(nil).UpdateProgress), ctx, id, status)
+}
@@ -5,7 +5,9 @@
 package mock_image
 
 import (
+	context "context"
 	reflect "reflect"
+	image "scrapper/utils/image"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,13 +36,13 @@ func (m *MockDownloader) EXPECT() *MockDownloaderMockRecorder {
 }
 
 // Download mocks base method.
-func (m *MockDownloader) Download(pathsChan chan string) {
+func (m *MockDownloader) Download(ctx context.Context, resultChan chan image.DownloadResult) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Download", pathsChan)
+	m.ctrl.Call(m, "Download", ctx, resultChan)
 }
 
 // Download indicates an expected call of Download.
-func (mr *MockDownloaderMockRecorder) Download(pathsChan interface{}) *gomock.Call {
+func (mr *MockDownloaderMockRecorder) Download(ctx, resultChan interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDownloader)(nil).Download), pathsChan)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDownloader)(nil).Download), ctx, resultChan)
 }
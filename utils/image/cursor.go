@@ -0,0 +1,127 @@
+package image
+
+import "math/rand"
+
+// a query is considered dry for a provider after maxEmptyPages result pages
+// in a row without any result, or maxStalePages in a row whose results were
+// all seen before (a resumed job walks through the pages it already scraped),
+// or after maxFailedPages failed requests in a row for the same page
+const (
+	maxEmptyPages  = 2
+	maxStalePages  = 5
+	maxFailedPages = 3
+)
+
+type cursorKey struct {
+	provider string
+	query    string
+}
+
+// pageCursor is the next result page to scrape for a provider and query
+type pageCursor struct {
+	page        int
+	emptyPages  int
+	stalePages  int
+	failedPages int
+	dry         bool
+}
+
+// queryCursors tracks the result page of every provider and query pair so
+// each visit moves on to new results, it is only used by the scraping loop
+type queryCursors struct {
+	cursors   map[cursorKey]*pageCursor
+	providers []SearchProvider
+	queries   *QueryList
+	dryCount  int
+	dryByName map[string]int //dry queries of every provider
+}
+
+func newQueryCursors(providers []SearchProvider, queries *QueryList) *queryCursors {
+	return &queryCursors{
+		cursors:   make(map[cursorKey]*pageCursor),
+		providers: providers,
+		queries:   queries,
+		dryByName: make(map[string]int, len(providers)),
+	}
+}
+
+func (q *queryCursors) get(provider SearchProvider, query string) *pageCursor {
+	key := cursorKey{provider: provider.Name(), query: query}
+	cursor, ok := q.cursors[key]
+	if !ok {
+		cursor = &pageCursor{}
+		q.cursors[key] = cursor
+	}
+	return cursor
+}
+
+// exhausted reports whether every provider ran dry for every query
+func (q *queryCursors) exhausted() bool {
+	return q.dryCount >= len(q.providers)*len(q.queries.Queries())
+}
+
+// next picks a random provider accepted by usable and a query that did not
+// run dry yet and returns the page to scrape, ok is false when all of them are
+// dry or no provider with queries left is usable
+func (q *queryCursors) next(r *rand.Rand, usable func(SearchProvider) bool) (provider SearchProvider, query string, page int, ok bool) {
+	candidates := make([]SearchProvider, 0, len(q.providers))
+	for _, p := range q.providers {
+		if q.dryByName[p.Name()] < len(q.queries.Queries()) && usable(p) {
+			candidates = append(candidates, p)
+		}
+	}
+	for len(candidates) > 0 {
+		provider = candidates[r.Intn(len(candidates))]
+		query = q.queries.Pick(r)
+		cursor := q.get(provider, query)
+		if !cursor.dry {
+			return provider, query, cursor.page, true
+		}
+	}
+	return nil, "", 0, false
+}
+
+// done records the number of image urls and new image urls found on the last
+// page of a provider and query, failed pages are retried and don't move the
+// cursor until they failed maxFailedPages times in a row
+func (q *queryCursors) done(provider SearchProvider, query string, urls, newURLs int64, failed bool) {
+	cursor := q.get(provider, query)
+	if cursor.dry {
+		return
+	}
+	if failed {
+		cursor.failedPages++
+		if cursor.failedPages >= maxFailedPages {
+			q.markDry(provider, query)
+		}
+		return
+	}
+	cursor.failedPages = 0
+	cursor.page++
+	switch {
+	case newURLs > 0:
+		cursor.emptyPages = 0
+		cursor.stalePages = 0
+		return
+	case urls > 0:
+		cursor.emptyPages = 0
+		cursor.stalePages++
+	default:
+		cursor.emptyPages++
+	}
+	if cursor.emptyPages >= maxEmptyPages || cursor.stalePages >= maxStalePages {
+		q.markDry(provider, query)
+	}
+}
+
+// markDry stops the scraping of a provider and query, like the result pages
+// of a query a robots.txt disallows
+func (q *queryCursors) markDry(provider SearchProvider, query string) {
+	cursor := q.get(provider, query)
+	if cursor.dry {
+		return
+	}
+	cursor.dry = true
+	q.dryCount++
+	q.dryByName[provider.Name()]++
+}
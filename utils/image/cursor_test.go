@@ -0,0 +1,121 @@
+package image
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestQueryCursors(t *testing.T) {
+	queries, err := NewQueryList([]Query{{Text: "cats", Weight: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := bingProvider{}
+
+	var tests = []struct {
+		name      string
+		pages     [][2]int64 //results and new results of every scraped page
+		failed    bool
+		mustPage  int
+		mustBeDry bool
+	}{
+		{
+			name:     "new results move to the next page",
+			pages:    [][2]int64{{35, 35}, {35, 20}},
+			mustPage: 2,
+		},
+		{
+			name:      "empty pages run dry",
+			pages:     [][2]int64{{35, 35}, {0, 0}, {0, 0}},
+			mustPage:  3,
+			mustBeDry: true,
+		},
+		{
+			name:     "empty page followed by new results",
+			pages:    [][2]int64{{0, 0}, {35, 1}, {0, 0}},
+			mustPage: 3,
+		},
+		{
+			name:      "seen results run dry slower",
+			pages:     [][2]int64{{35, 0}, {35, 0}, {35, 0}, {35, 0}, {35, 0}},
+			mustPage:  5,
+			mustBeDry: true,
+		},
+		{
+			name:     "failed pages are retried",
+			pages:    [][2]int64{{0, 0}, {0, 0}},
+			failed:   true,
+			mustPage: 0,
+		},
+		{
+			name:      "pages failing in a row run dry",
+			pages:     [][2]int64{{0, 0}, {0, 0}, {0, 0}},
+			failed:    true,
+			mustPage:  0,
+			mustBeDry: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursors := newQueryCursors([]SearchProvider{provider}, queries)
+			for _, page := range test.pages {
+				cursors.done(provider, "cats", page[0], page[1], test.failed)
+			}
+			cursor := cursors.get(provider, "cats")
+			if cursor.page != test.mustPage {
+				t.Errorf("page:%d is not equal to:%d", cursor.page, test.mustPage)
+			}
+			if cursor.dry != test.mustBeDry {
+				t.Errorf("dry:%t is not equal to:%t", cursor.dry, test.mustBeDry)
+			}
+			_, _, _, ok := cursors.next(rand.New(rand.NewSource(1)), func(SearchProvider) bool { return true })
+			if ok == test.mustBeDry {
+				t.Errorf("next returned ok:%t for a dry:%t cursor", ok, test.mustBeDry)
+			}
+		})
+	}
+}
+
+func TestQueryCursors_RepeatedQueriesRunDry(t *testing.T) {
+	//a repeated query used to leave the dry count short of the total so next never ended
+	queries, err := NewQueryList([]Query{{Text: "cats", Weight: 1}, {Text: "dogs", Weight: 1}, {Text: "cats", Weight: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := []SearchProvider{bingProvider{}, duckDuckGoProvider{}}
+	cursors := newQueryCursors(providers, queries)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		provider, query, _, ok := cursors.next(r, func(SearchProvider) bool { return true })
+		if !ok {
+			if !cursors.exhausted() {
+				t.Fatal("next returned ok:false before the cursors were exhausted")
+			}
+			return
+		}
+		cursors.done(provider, query, 0, 0, false)
+	}
+	t.Fatal("the cursors must be exhausted after every query ran dry")
+}
+
+func TestQueryCursors_FailedPagesRunDry(t *testing.T) {
+	queries, err := NewQueryList([]Query{{Text: "cats", Weight: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := bingProvider{}
+	cursors := newQueryCursors([]SearchProvider{provider}, queries)
+	//a page that loads resets the failures
+	for _, failed := range []bool{true, true, false, true, true} {
+		cursors.done(provider, "cats", 35, 35, failed)
+	}
+	if cursor := cursors.get(provider, "cats"); cursor.dry || cursor.page != 1 {
+		t.Fatalf("page:%d dry:%t, the failures must not be in a row yet", cursor.page, cursor.dry)
+	}
+	//an engine answering 404 forever must not keep the job running
+	cursors.done(provider, "cats", 0, 0, true)
+	if !cursors.exhausted() {
+		t.Error("the cursors must be exhausted once the page failed too many times in a row")
+	}
+}
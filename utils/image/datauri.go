@@ -0,0 +1,86 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidDataURI = errors.New("invalid data uri")
+
+func isDataURI(u string) bool {
+	return len(u) > 5 && strings.EqualFold(u[:5], "data:")
+}
+
+// decodeDataURI returns the media type and the bytes of a
+// "data:[<mediatype>][;base64],<data>" uri
+func decodeDataURI(uri string) (string, []byte, error) {
+	if !isDataURI(uri) {
+		return "", nil, ErrInvalidDataURI
+	}
+	header, payload, ok := strings.Cut(uri[5:], ",")
+	if !ok {
+		return "", nil, ErrInvalidDataURI
+	}
+	mediaType := header
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		mediaType = header[:len(header)-len(";base64")]
+		isBase64 = true
+	}
+	if mediaType == "" {
+		mediaType = "text/plain;charset=US-ASCII"
+	}
+
+	if !isBase64 {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", nil, errors.Join(ErrInvalidDataURI, err)
+		}
+		return mediaType, []byte(data), nil
+	}
+	//google pads some thumbnails and not others, and they may be url escaped
+	payload, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", nil, errors.Join(ErrInvalidDataURI, err)
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		return "", nil, errors.Join(ErrInvalidDataURI, err)
+	}
+	return mediaType, data, nil
+}
+
+// sourceKey identifies an image url in the seen urls and the source_url
+// column, data uris are replaced by a digest so kilobytes of base64 are not stored
+func sourceKey(u string) string {
+	if !isDataURI(u) {
+		return u
+	}
+	mediaType, _, _ := strings.Cut(u[5:], ",")
+	sum := sha256.Sum256([]byte(u))
+	return "data:" + mediaType + ";sha256," + hex.EncodeToString(sum[:])
+}
+
+// lazyImageURL returns the best image url of an <img> element, lazy loaded
+// images keep the real url in data-iurl or data-src while src holds a base64
+// placeholder, so an http url is preferred over a data uri
+func lazyImageURL(attr func(string) string) string {
+	var fallback string
+	for _, name := range []string{"data-iurl", "data-src", "src"} {
+		value := strings.TrimSpace(attr(name))
+		if value == "" {
+			continue
+		}
+		if !isDataURI(value) {
+			return value
+		}
+		if fallback == "" {
+			fallback = value
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,89 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDataURI(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	pngData := buf.Bytes()
+	padded := base64.StdEncoding.EncodeToString(pngData)
+
+	var tests = []struct {
+		name          string
+		uri           string
+		mustMediaType string
+		mustData      []byte
+		mustFail      bool
+	}{
+		{
+			name:          "padded base64",
+			uri:           "data:image/png;base64," + padded,
+			mustMediaType: "image/png",
+			mustData:      pngData,
+		},
+		{
+			name:          "unpadded base64",
+			uri:           "data:image/png;base64," + strings.TrimRight(padded, "="),
+			mustMediaType: "image/png",
+			mustData:      pngData,
+		},
+		{
+			name:          "percent encoded",
+			uri:           "data:text/plain,hello%20world",
+			mustMediaType: "text/plain",
+			mustData:      []byte("hello world"),
+		},
+		{
+			name:     "missing comma",
+			uri:      "data:image/png;base64",
+			mustFail: true,
+		},
+		{
+			name:     "not a data uri",
+			uri:      "https://example.com/a.png",
+			mustFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mediaType, data, err := decodeDataURI(test.uri)
+			if test.mustFail {
+				if err == nil {
+					t.Errorf("expected an error for %q", test.uri)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mediaType != test.mustMediaType {
+				t.Errorf("media type:%s is not equal to:%s", mediaType, test.mustMediaType)
+			}
+			if !bytes.Equal(data, test.mustData) {
+				t.Errorf("decoded data is not equal to the encoded data")
+			}
+		})
+	}
+}
+
+func TestLazyImageURL(t *testing.T) {
+	placeholder := "data:image/gif;base64,R0lGODlhAQABAAAAACw="
+	attrs := map[string]string{"src": placeholder, "data-src": "https://example.com/real.jpg"}
+	if u := lazyImageURL(func(name string) string { return attrs[name] }); u != attrs["data-src"] {
+		t.Errorf("url:%s is not equal to:%s", u, attrs["data-src"])
+	}
+	attrs = map[string]string{"src": placeholder}
+	if u := lazyImageURL(func(name string) string { return attrs[name] }); u != placeholder {
+		t.Errorf("url:%s is not equal to the data uri", u)
+	}
+}
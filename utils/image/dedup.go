@@ -0,0 +1,126 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/bits"
+	"sync"
+
+	"github.com/nfnt/resize"
+)
+
+var (
+	ErrDuplicate     = errors.New("duplicate image")
+	ErrNearDuplicate = errors.New("near duplicate image")
+)
+
+// PixelHash returns the hex encoded SHA-256 of the decoded pixels, so the same
+// picture hashes the same whatever encoder or container it was served with
+func PixelHash(img image.Image) string {
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+
+	h := sha256.New()
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint32(size[:4], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(size[4:], uint32(b.Dy()))
+	h.Write(size)
+	h.Write(rgba.Pix)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DifferenceHash returns the 64 bit dHash of img, each bit tells whether a
+// pixel of a 9x8 grayscale thumbnail is brighter than its right neighbour
+func DifferenceHash(img image.Image) uint64 {
+	small := resize.Resize(9, 8, img, resize.Bilinear)
+	b := small.Bounds()
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := color.GrayModel.Convert(small.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+			right := color.GrayModel.Convert(small.At(b.Min.X+x+1, b.Min.Y+y)).(color.Gray).Y
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// HashIndex remembers the hashes of stored images to detect exact and near duplicates,
+// a negative maxDistance disables near duplicate detection
+type HashIndex struct {
+	mtx         sync.Mutex
+	pixels      map[string]struct{}
+	perceptual  []uint64
+	maxDistance int
+}
+
+func NewHashIndex(maxDistance int) *HashIndex {
+	return &HashIndex{
+		pixels:      make(map[string]struct{}),
+		perceptual:  make([]uint64, 0),
+		maxDistance: maxDistance,
+	}
+}
+
+// Add records the hashes of an image that is already stored
+func (h *HashIndex) Add(pixelHash string, perceptualHash uint64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.add(pixelHash, perceptualHash)
+}
+
+func (h *HashIndex) add(pixelHash string, perceptualHash uint64) {
+	h.pixels[pixelHash] = struct{}{}
+	h.perceptual = append(h.perceptual, perceptualHash)
+}
+
+// CheckAndAdd returns ErrDuplicate or ErrNearDuplicate when the image is
+// already known, otherwise it records the hashes and returns nil
+func (h *HashIndex) CheckAndAdd(pixelHash string, perceptualHash uint64) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if _, ok := h.pixels[pixelHash]; ok {
+		return ErrDuplicate
+	}
+	if h.maxDistance >= 0 {
+		for _, known := range h.perceptual {
+			if HammingDistance(known, perceptualHash) <= h.maxDistance {
+				return ErrNearDuplicate
+			}
+		}
+	}
+	h.add(pixelHash, perceptualHash)
+	return nil
+}
+
+// Remove forgets the hashes of an image that was not stored after all
+func (h *HashIndex) Remove(pixelHash string, perceptualHash uint64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	delete(h.pixels, pixelHash)
+	for i := len(h.perceptual) - 1; i >= 0; i-- {
+		if h.perceptual[i] == perceptualHash {
+			h.perceptual = append(h.perceptual[:i], h.perceptual[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *HashIndex) Len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.pixels)
+}
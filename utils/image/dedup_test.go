@@ -0,0 +1,187 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// gradient returns a w x h image getting brighter to the right, flip makes it
+// brighter to the left
+func gradient(w, h int, flip bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / (w - 1))
+			if flip {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{R: v, G: uint8(y * 255 / (h - 1)), B: v / 2, A: 255})
+		}
+	}
+	return img
+}
+
+// reencode decodes img after encoding it with encode
+func reencode(t *testing.T, img image.Image, encode func(*bytes.Buffer, image.Image) error) image.Image {
+	buf := &bytes.Buffer{}
+	if err := encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := image.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestPixelHash(t *testing.T) {
+	img := gradient(40, 30, false)
+	hash := PixelHash(img)
+	var tests = []struct {
+		name     string
+		img      image.Image
+		mustSame bool
+	}{
+		{
+			name: "png",
+			img: reencode(t, img, func(buf *bytes.Buffer, img image.Image) error {
+				return png.Encode(buf, img)
+			}),
+			mustSame: true,
+		},
+		{
+			name: "lossless webp",
+			img: reencode(t, img, func(buf *bytes.Buffer, img image.Image) error {
+				return nativewebp.Encode(buf, img, nil)
+			}),
+			mustSame: true,
+		},
+		{
+			name: "offset bounds",
+			img: func() image.Image {
+				offset := image.NewRGBA(image.Rect(10, 10, 50, 40))
+				draw.Draw(offset, offset.Bounds(), img, image.Point{}, draw.Src)
+				return offset
+			}(),
+			mustSame: true,
+		},
+		{
+			name: "one pixel changed",
+			img: func() image.Image {
+				changed := gradient(40, 30, false)
+				changed.Set(5, 5, color.RGBA{A: 255})
+				return changed
+			}(),
+		},
+		{
+			name: "same pixels in another size",
+			img:  gradient(30, 40, false),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if same := PixelHash(test.img) == hash; same != test.mustSame {
+				t.Errorf("same hash:%t, must be %t", same, test.mustSame)
+			}
+		})
+	}
+}
+
+func TestDifferenceHash(t *testing.T) {
+	img := gradient(64, 48, false)
+	hash := DifferenceHash(img)
+
+	altered := gradient(64, 48, false)
+	for x := 30; x < 34; x++ {
+		altered.Set(x, 20, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	var tests = []struct {
+		name     string
+		img      image.Image
+		mustNear bool
+	}{
+		{name: "slightly altered", img: altered, mustNear: true},
+		{name: "scaled", img: gradient(128, 96, false), mustNear: true},
+		{name: "different", img: gradient(64, 48, true)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance := HammingDistance(hash, DifferenceHash(test.img))
+			if near := distance <= DefaultMaxHammingDistance; near != test.mustNear {
+				t.Errorf("distance is %d, near:%t must be %t", distance, near, test.mustNear)
+			}
+		})
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	var tests = []struct {
+		a, b         uint64
+		mustDistance int
+	}{
+		{a: 0, b: 0, mustDistance: 0},
+		{a: 0b1011, b: 0b0001, mustDistance: 2},
+		{a: 0, b: ^uint64(0), mustDistance: 64},
+	}
+	for _, test := range tests {
+		if distance := HammingDistance(test.a, test.b); distance != test.mustDistance {
+			t.Errorf("distance of %b and %b is %d, must be %d", test.a, test.b, distance, test.mustDistance)
+		}
+	}
+}
+
+func TestHashIndex_CheckAndAdd(t *testing.T) {
+	var tests = []struct {
+		name        string
+		maxDistance int
+		pixelHash   string
+		perceptual  uint64
+		mustErr     error
+	}{
+		{name: "same pixels", maxDistance: 4, pixelHash: "a", perceptual: 0xff00, mustErr: ErrDuplicate},
+		{name: "near perceptual hash", maxDistance: 4, pixelHash: "b", perceptual: 0xff0f, mustErr: ErrNearDuplicate},
+		{name: "far perceptual hash", maxDistance: 4, pixelHash: "b", perceptual: 0x00ff},
+		{name: "near detection disabled", maxDistance: -1, pixelHash: "b", perceptual: 0xff00},
+		{name: "zero distance only matches equal hashes", maxDistance: 0, pixelHash: "b", perceptual: 0xff01},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := NewHashIndex(test.maxDistance)
+			h.Add("a", 0xff00)
+			if err := h.CheckAndAdd(test.pixelHash, test.perceptual); !errors.Is(err, test.mustErr) {
+				t.Fatalf("error is %v, must be %v", err, test.mustErr)
+			}
+			mustLen := 1
+			if test.mustErr == nil {
+				mustLen = 2
+			}
+			if h.Len() != mustLen {
+				t.Errorf("index holds %d images, must hold %d", h.Len(), mustLen)
+			}
+		})
+	}
+}
+
+func TestHashIndex_Remove(t *testing.T) {
+	h := NewHashIndex(DefaultMaxHammingDistance)
+	if err := h.CheckAndAdd("a", 0xff00); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CheckAndAdd("a", 0xff00); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("error is %v, must be ErrDuplicate", err)
+	}
+	h.Remove("a", 0xff00)
+	if h.Len() != 0 {
+		t.Errorf("index holds %d images after Remove", h.Len())
+	}
+	if err := h.CheckAndAdd("a", 0xff00); err != nil {
+		t.Errorf("a removed image must be added again, error is %v", err)
+	}
+}
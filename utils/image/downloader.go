@@ -3,114 +3,236 @@ package image
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"image"
-	"image/jpeg"
 	"io"
+	"math"
 	"math/rand"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	logger "scrapper/infrastructure/log"
+	"scrapper/infrastructure/storage"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocolly/colly"
-	"github.com/nfnt/resize"
-	"golang.org/x/time/rate"
 )
 
 const (
 	numWorkers       = 10000
-	rateLimit        = 1000 //maximum 1000 image download per second (iran network cant reach maximum but its possible in a server with good resources and internet)
 	imageWidth       = 100
 	downloadQueueCap = 100000
 	proxyFetchURL    = "https://www.sslproxies.org/"
+
+	DefaultMaxHammingDistance = 4
 )
 
-var petQueries = []string{
-	"cute kittens", "puppies", "hamsters", "bunnies", "goldfish",
-	"parrots", "turtles", "guinea pigs", "hedgehogs", "ferrets",
-	"pet snakes", "pet lizards", "pet frogs", "pet spiders", "pet mice",
-	"pet rats", "pet birds", "pet rabbits", "pet ducks", "pet chickens",
+type Downloader interface {
+	// Download sends the stored images to resultChan and closes it once the
+	// target count is reached or ctx is cancelled
+	Download(ctx context.Context, resultChan chan DownloadResult)
 }
 
-type Downloader interface {
-	Download(pathsChan chan string)
+// DownloadResult describes a stored image and where it came from
+type DownloadResult struct {
+	File           string //storage key of the main file
+	StorageBackend string
+	SourceURL      string
+	SearchEngine   string
+	Query          string
+	OriginalWidth  int
+	OriginalHeight int
+	Width          int
+	Height         int
+	ByteSize       int64
+	ContentType    string
+	SHA256         string
+	PixelHash      string
+	PerceptualHash uint64
+	JobID          int64
+	Variants       []VariantResult
+	CreatedAt      time.Time
 }
 
-type SearchEngine struct {
-	Name       string
-	SearchURL  string
-	ResultAttr string
-	Extractor  func(*colly.HTMLElement) string
+// VariantResult describes one stored output variant of an image
+type VariantResult struct {
+	Name        string
+	File        string
+	Width       int
+	Height      int
+	ByteSize    int64
+	ContentType string
+	SHA256      string
 }
 
-var searchEngines = []SearchEngine{
-	{"Google", "https://www.google.com/search?tbm=isch&q=%s", "img",
-		func(element *colly.HTMLElement) string {
-			return element.Attr("src")
-		}},
-	{"Bing", "https://www.bing.com/images/search?q=%s", "a.iusc", extractImageURLFromBing},
+type encodedVariant struct {
+	data   []byte
+	result VariantResult
 }
 
-func extractImageURLFromBing(e *colly.HTMLElement) string {
-	data := e.Attr("m")
-	start := strings.Index(data, `"murl":"`)
-	if start == -1 {
-		return ""
-	}
-	start += len(`"murl":"`)
-	end := strings.Index(data[start:], `"`)
-	if end == -1 {
-		return ""
-	}
-	return data[start : start+end]
+type downloadTask struct {
+	url    string
+	engine string
+	query  string
 }
 
 type DownloadResizer struct {
-	downloadQueue chan string
-	saveDirectory string
+	downloadQueue *hostQueues
+	storage       storage.Storage
 	logger        logger.Logger
 	count         uint64
 	targetCount   uint64
-	limiter       *rate.Limiter
+	imageHosts    *HostLimiter
+	searchHosts   *HostLimiter
+	health        *engineHealth
+	reserved      uint64 //images being stored, they count once their files are written
+	polite        *Politeness
+	robots        *robotsRules //nil unless polite
 	mtx           *sync.Mutex
 	proxies       []string
 	rand          *rand.Rand
 	ctx           context.Context
 	cancelCtx     context.CancelFunc
 	proxy         bool
-	resultChan    chan string
+	resultChan    chan DownloadResult
+	queries       *QueryList
+	hashIndex     *HashIndex
+	stats         *runStats
+	filter        Filter
+	jobID         int64
+	seenURLs      map[string]struct{}
+	seenMtx       *sync.Mutex
+	onSeenURL     func(string)
+	onEvent       func(Event)
+	providers     []SearchProvider
+	exhausted     bool
+	variants      []Variant
+	layout        Layout
 }
 
-func NewDownloadResizer(saveDir string, targetCount uint64, lg logger.Logger, proxy bool) *DownloadResizer {
+type Config struct {
+	// Storage receives the files of every stored image
+	Storage     storage.Storage
+	TargetCount uint64
+	Proxy       bool
+	// Queries to scrape, the built-in pet queries are used when nil
+	Queries *QueryList
+	// Providers are the search engines to scrape, every registered provider is used when nil
+	Providers []SearchProvider
+	// Filter rejects unwanted source images, the zero Filter accepts everything
+	Filter Filter
+	// Variants are the outputs stored for every image, the first one is the
+	// main file of the image. DefaultVariant is used when empty
+	Variants []Variant
+	// Layout decides the storage keys of the files, DefaultLayout is used when nil
+	Layout *Layout
+	// ImageHostLimits throttle the image downloads of every host separately,
+	// DefaultImageHostLimits are used when nil
+	ImageHostLimits *HostLimits
+	// SearchHostLimits throttle the search page requests of every search
+	// engine host, DefaultSearchHostLimits are used when nil
+	SearchHostLimits *HostLimits
+	// Polite makes the run obey robots.txt, wait between search pages and send
+	// an identifying user agent, nil turns polite mode off
+	Polite *Politeness
+	// HashIndex holds the hashes of the images stored before this run, when nil
+	// only duplicates within this run are detected
+	HashIndex *HashIndex
+
+	// JobID is attached to every result, zero means the images belong to no job
+	JobID int64
+	// InitialCount is the number of images a resumed job already saved
+	InitialCount uint64
+	// SeenURLs are image urls tried by a previous run of the job, they are skipped
+	SeenURLs []string
+	// OnSeenURL is called once for every new image url so it can be persisted
+	OnSeenURL func(string)
+	// OnEvent is called when an image is downloaded, rejected or saved. It is
+	// called concurrently by the workers, so saved events may arrive slightly
+	// out of count order, and must return quickly
+	OnEvent func(Event)
+}
+
+func NewDownloadResizer(cfg Config, lg logger.Logger) *DownloadResizer {
+	if cfg.Queries == nil {
+		cfg.Queries = DefaultQueryList()
+	}
+	if cfg.HashIndex == nil {
+		cfg.HashIndex = NewHashIndex(DefaultMaxHammingDistance)
+	}
+	if len(cfg.Variants) == 0 {
+		cfg.Variants = []Variant{DefaultVariant()}
+	}
+	if len(cfg.Providers) == 0 {
+		cfg.Providers, _ = ResolveProviders(nil, nil)
+	}
+	layout := DefaultLayout()
+	if cfg.Layout != nil {
+		layout = *cfg.Layout
+	}
+	imageHostLimits := DefaultImageHostLimits()
+	if cfg.ImageHostLimits != nil {
+		imageHostLimits = *cfg.ImageHostLimits
+	}
+	searchHostLimits := DefaultSearchHostLimits()
+	if cfg.SearchHostLimits != nil {
+		searchHostLimits = *cfg.SearchHostLimits
+	}
+	var robots *robotsRules
+	if cfg.Polite != nil {
+		robots = newRobotsRules(cfg.Polite.UserAgent)
+	}
+	seenURLs := make(map[string]struct{}, len(cfg.SeenURLs))
+	for _, u := range cfg.SeenURLs {
+		seenURLs[u] = struct{}{}
+	}
 	s := rand.NewSource(time.Now().UnixNano())
-	ctx, cancelFunc := context.WithCancel(context.Background())
 	return &DownloadResizer{
-		downloadQueue: make(chan string, downloadQueueCap),
-		saveDirectory: saveDir,
-		targetCount:   targetCount,
-		logger:        lg,
-		limiter:       rate.NewLimiter(rate.Limit(rateLimit), rateLimit),
-		mtx:           &sync.Mutex{},
-		rand:          rand.New(rand.New(s)),
-		ctx:           ctx,
-		cancelCtx:     cancelFunc,
-		proxy:         proxy,
+		storage:     cfg.Storage,
+		targetCount: cfg.TargetCount,
+		count:       cfg.InitialCount,
+		logger:      lg,
+		imageHosts:  NewHostLimiter(imageHostLimits),
+		searchHosts: NewHostLimiter(searchHostLimits),
+		health:      newEngineHealth(cfg.Providers),
+		polite:      cfg.Polite,
+		robots:      robots,
+		mtx:         &sync.Mutex{},
+		rand:        rand.New(rand.New(s)),
+		proxy:       cfg.Proxy,
+		queries:     cfg.Queries,
+		hashIndex:   cfg.HashIndex,
+		jobID:       cfg.JobID,
+		seenURLs:    seenURLs,
+		seenMtx:     &sync.Mutex{},
+		onSeenURL:   cfg.OnSeenURL,
+		onEvent:     cfg.OnEvent,
+		providers:   cfg.Providers,
+		variants:    cfg.Variants,
+		filter:      cfg.Filter,
+		stats:       newRunStats(),
+		layout:      layout,
 	}
 }
 
-// Download: returns channel of file paths
-func (d *DownloadResizer) Download(pathsChan chan string) {
-	d.resultChan = pathsChan
+// Download: sends the stored images to resultChan until the target count is reached or ctx is cancelled
+func (d *DownloadResizer) Download(ctx context.Context, resultChan chan DownloadResult) {
+	d.resultChan = resultChan
+	d.ctx, d.cancelCtx = context.WithCancel(ctx)
+	defer d.cancelCtx()
+	if d.count >= d.targetCount {
+		d.cancelCtx()
+	}
 	if d.proxy {
 		//we refresh proxies for every download starting command because of awful connection quality of free proxies
 		go func() {
 			for {
-				if d.count < d.targetCount {
+				if d.count < d.targetCount && d.ctx.Err() == nil {
 					if err := d.fetchProxies(); err != nil {
 						d.logger.Warning("failed to fetch new proxies proxies:" + err.Error())
 						d.logger.Info("running program without proxies...")
@@ -123,16 +245,47 @@ func (d *DownloadResizer) Download(pathsChan chan string) {
 			}
 		}()
 	}
-	// Start workers to process image URLs
-	for i := 0; i < numWorkers; i++ {
-		go d.worker()
-	}
+	//image urls are downloaded by the workers of their host, a host can't take more workers than its in flight requests
+	d.downloadQueue = newHostQueues(d.imageHosts.limits.MaxInFlight, numWorkers, downloadQueueCap, d.process)
 
 	c := colly.NewCollector(
 		colly.Async(true),
 	)
 	c.AllowURLRevisit = true
 	c.SetRequestTimeout(time.Second * 2)
+	if d.polite != nil {
+		if err := d.polite.apply(c); err != nil {
+			d.logger.Error(err)
+		}
+	}
+	for _, provider := range d.providers {
+		provider := provider
+		c.OnHTML(provider.Selector(), func(e *colly.HTMLElement) {
+			//the images of CAPTCHA and consent pages are no results
+			if e.Request.Ctx.Get("engine") != provider.Name() || e.Request.Ctx.Get("blocked") != "" {
+				return
+			}
+			for _, imgURL := range provider.Extract(e) {
+				if imgURL == "" {
+					continue
+				}
+				e.Request.Ctx.GetAny("results").(*atomic.Int64).Add(1)
+				if d.markSeen(imgURL) {
+					e.Request.Ctx.GetAny("found").(*atomic.Int64).Add(1)
+					d.downloadQueue.push(downloadTask{url: imgURL, engine: provider.Name(), query: e.Request.Ctx.Get("query")})
+				}
+			}
+		})
+	}
+	//OnResponse runs before OnHTML so the results of block pages are ignored
+	c.OnResponse(func(r *colly.Response) {
+		markBlocked(r)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		r.Ctx.Put("failed", "true")
+		markBlocked(r)
+	})
+	cursors := newQueryCursors(d.providers, d.queries)
 
 loop:
 	for {
@@ -140,57 +293,328 @@ loop:
 		case <-d.ctx.Done():
 			break loop
 		default:
-			engine := searchEngines[d.rand.Intn(len(searchEngines))]
-			c.OnHTML(engine.ResultAttr, func(e *colly.HTMLElement) {
-				imgURL := engine.Extractor(e)
-				if imgURL != "" {
-					d.downloadQueue <- imgURL
+			provider, query, page, ok := cursors.next(d.rand, d.health.available)
+			if !ok && cursors.exhausted() {
+				d.exhausted = true
+				d.logger.Warning(fmt.Sprintf("every query ran out of new results after %d images", d.count))
+				break loop
+			}
+			if !ok {
+				wait := d.health.untilResume()
+				d.logger.Warning(fmt.Sprintf("every search engine with queries left is paused, resuming in %s", wait.Round(time.Second)))
+				select {
+				case <-time.After(wait):
+				case <-d.ctx.Done():
+				}
+				continue
+			}
+			searchURL := provider.BuildURL(query, page)
+			d.logger.Info(fmt.Sprintf("Scraping %s page %d for '%s'...\n", provider.Name(), page+1, strings.ReplaceAll(query, " ", "+")))
+
+			results, found := &atomic.Int64{}, &atomic.Int64{}
+			reqCtx := colly.NewContext()
+			reqCtx.Put("engine", provider.Name())
+			reqCtx.Put("query", query)
+			reqCtx.Put("results", results)
+			reqCtx.Put("found", found)
+			release, err := d.searchHosts.Acquire(d.ctx, hostOf(searchURL))
+			if err != nil {
+				continue
+			}
+			if err := c.Request("GET", searchURL, nil, reqCtx, nil); err != nil {
+				release()
+				if errors.Is(err, colly.ErrRobotsTxtBlocked) {
+					d.logger.Info(fmt.Sprintf("robots.txt of %s disallows '%s'", provider.Name(), query))
+					cursors.markDry(provider, query)
+					continue
 				}
-			})
-			query := petQueries[d.rand.Intn(len(petQueries))]
-			searchURL := fmt.Sprintf(engine.SearchURL, url.QueryEscape(query))
-			d.logger.Info(fmt.Sprintf("Scraping %s for '%s'...\n", engine.Name, strings.ReplaceAll(query, " ", "+")))
-
-			if err := c.Visit(searchURL); err != nil {
 				d.logger.Error(err)
+				cursors.done(provider, query, 0, 0, true)
 				continue
 			}
 			c.Wait()
+			release()
+			blocked := reqCtx.Get("blocked")
+			if blocked != "" {
+				wait, _ := reqCtx.GetAny("retry_after").(time.Duration)
+				pause := d.health.blocked(provider.Name(), blocked, wait)
+				d.logger.Warning(fmt.Sprintf("%s blocked the scraper (%s), pausing it for %s", provider.Name(), blocked, pause))
+			} else if reqCtx.Get("failed") == "" && d.health.ok(provider.Name()) {
+				d.logger.Info(fmt.Sprintf("%s answers again after being paused", provider.Name()))
+			}
+			//blocked pages are retried once the engine is resumed, the backoff of the engine bounds the retries
+			if blocked == "" {
+				cursors.done(provider, query, results.Load(), found.Load(), reqCtx.Get("failed") != "")
+			}
+			if cursors.get(provider, query).dry {
+				d.logger.Info(fmt.Sprintf("%s ran out of new results for '%s'", provider.Name(), query))
+			}
 		}
 	}
-	close(d.downloadQueue)
+	//workers skip the queued urls once the context is done, so waiting for them is quick
+	d.downloadQueue.wait()
 	close(d.resultChan)
 
+	d.logger.Info("run stats: " + d.Stats().String())
+	if ctx.Err() != nil {
+		d.logger.Info(fmt.Sprintf("Download interrupted after %d images.", d.count))
+		return
+	}
 	d.logger.Info("Finished downloading and processing images.")
 
 	return
 }
 
-func (d *DownloadResizer) worker() {
+// markBlocked flags the search page request of r when the engine answered with
+// a rate limit, CAPTCHA or consent page instead of results
+func markBlocked(r *colly.Response) {
+	var header http.Header
+	if r.Headers != nil {
+		header = *r.Headers
+	}
+	if reason, wait, ok := detectBlock(r.StatusCode, header, r.Request.URL.String(), r.Body); ok {
+		r.Ctx.Put("blocked", reason)
+		r.Ctx.Put("retry_after", wait)
+	}
+}
+
+// Exhausted reports whether the last Download stopped because every query ran
+// out of new results before the target count was reached
+func (d *DownloadResizer) Exhausted() bool {
+	return d.exhausted
+}
+
+// markSeen reports whether imgURL is new and remembers it
+func (d *DownloadResizer) markSeen(imgURL string) bool {
+	key := sourceKey(imgURL)
+	d.seenMtx.Lock()
+	defer d.seenMtx.Unlock()
+	if _, ok := d.seenURLs[key]; ok {
+		return false
+	}
+	d.seenURLs[key] = struct{}{}
+	if d.onSeenURL != nil {
+		d.onSeenURL(key)
+	}
+	return true
+}
+
+// process downloads the image of task unless the run is over
+func (d *DownloadResizer) process(task downloadTask) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	d.countResult(task, d.downloadAndResizeImage(task))
+}
+
+// countResult adds the outcome of a download to the run statistics
+func (d *DownloadResizer) countResult(task downloadTask, err error) {
+	var rejected *RejectedError
+	switch {
+	case err == nil:
+	case errors.As(err, &rejected):
+		d.stats.update(func(s *Stats) { s.Rejected[rejected.Reason]++ })
+		d.logger.Info(fmt.Sprintf("rejected %s: %s", task.url, rejected.Reason+": "+rejected.Detail))
+		d.emit(task, Event{Kind: EventRejected, Reason: rejected.Reason})
+	case errors.Is(err, ErrDuplicate), errors.Is(err, ErrNearDuplicate):
+		d.stats.update(func(s *Stats) { s.Duplicates++ })
+		d.logger.Info(fmt.Sprintf("skipped %s from %s", err, task.url))
+		d.emit(task, Event{Kind: EventRejected, Reason: err.Error()})
+	case d.ctx.Err() != nil:
+		//downloads aborted by the end of the run are not failures
+	default:
+		d.stats.update(func(s *Stats) { s.Failed++ })
+	}
+}
+
+// maxBodySize is the number of bytes read from an image response, one more
+// than the filter allows so larger bodies can be detected
+func (d *DownloadResizer) maxBodySize() int64 {
+	if d.filter.MaxBytes > 0 {
+		return d.filter.MaxBytes + 1
+	}
+	return math.MaxInt64
+}
+
+// Stats returns the counters of the current or last Download
+func (d *DownloadResizer) Stats() Stats {
+	s := d.stats.snapshot()
+	s.Engines = d.health.snapshot()
+	return s
+}
+
+func (d *DownloadResizer) downloadAndResizeImage(task downloadTask) (err error) {
+	body, err := d.fetch(task.url)
+	if err != nil {
+		return err
+	}
+	d.emit(task, Event{Kind: EventDownloaded})
+	if err = d.filter.CheckContentType(http.DetectContentType(body)); err != nil {
+		return err
+	}
+	//the header is enough to check the dimensions, big images are rejected before decoding them
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err = d.filter.CheckDimensions(imgConfig.Width, imgConfig.Height); err != nil {
+		return err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	pixelHash := PixelHash(img)
+	perceptualHash := DifferenceHash(img)
+
+	outputs := make([]encodedVariant, 0, len(d.variants))
+	for _, v := range d.variants {
+		m := v.Resize(img)
+		encoded := &bytes.Buffer{}
+		if err = v.Encode(encoded, m); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(encoded.Bytes())
+		//files are named after their content so concurrent workers never pick the same name
+		filePath := d.layout.Key(hex.EncodeToString(sum[:]), v.Extension())
+		outputs = append(outputs, encodedVariant{data: encoded.Bytes(), result: VariantResult{
+			Name:        v.Name,
+			File:        filePath,
+			Width:       m.Bounds().Dx(),
+			Height:      m.Bounds().Dy(),
+			ByteSize:    int64(encoded.Len()),
+			ContentType: v.ContentType(),
+			SHA256:      hex.EncodeToString(sum[:]),
+		}})
+	}
+	primary := outputs[0].result
+	result := DownloadResult{
+		File:           primary.File,
+		SourceURL:      sourceKey(task.url),
+		SearchEngine:   task.engine,
+		Query:          task.query,
+		OriginalWidth:  img.Bounds().Dx(),
+		OriginalHeight: img.Bounds().Dy(),
+		Width:          primary.Width,
+		Height:         primary.Height,
+		ByteSize:       primary.ByteSize,
+		ContentType:    primary.ContentType,
+		SHA256:         primary.SHA256,
+		PixelHash:      pixelHash,
+		PerceptualHash: perceptualHash,
+		JobID:          d.jobID,
+		StorageBackend: d.storage.Name(),
+		Variants:       make([]VariantResult, 0, len(outputs)),
+	}
+	for _, output := range outputs {
+		result.Variants = append(result.Variants, output.result)
+	}
+
+	//only the reservation and the duplicate check hold the lock, the files are
+	//stored concurrently so a slow upload doesn't hold back the other workers
+	reserved, err := d.reserve(pixelHash, perceptualHash)
+	if !reserved {
+		return err
+	}
+	written := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		key := output.result.File
+		written = append(written, key)
+		if err = d.storage.Put(d.ctx, key, bytes.NewReader(output.data), int64(len(output.data)), output.result.ContentType); err != nil {
+			d.release(pixelHash, perceptualHash, written)
+			return err
+		}
+	}
+	//every file is completely stored now, only then the result may reach the database
+	count := d.commit()
+	d.stats.update(func(s *Stats) { s.Saved++ })
+	result.CreatedAt = time.Now()
+	d.resultChan <- result
+	d.logger.Info(fmt.Sprintf("downloaded %d images", count))
+	d.emit(task, Event{Kind: EventSaved, File: result.File, Count: count})
+
+	return nil
+}
+
+// reserve takes one of the images left to reach the target count and records
+// the hashes of the image. It returns false once the target count is reached
+// or the run is done, and ErrDuplicate or ErrNearDuplicate for known images
+func (d *DownloadResizer) reserve(pixelHash string, perceptualHash uint64) (bool, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.count+d.reserved >= d.targetCount || d.ctx.Err() != nil {
+		return false, nil
+	}
+	//duplicates are skipped before writing so they never count toward targetCount
+	if err := d.hashIndex.CheckAndAdd(pixelHash, perceptualHash); err != nil {
+		return false, err
+	}
+	d.reserved++
+	return true, nil
+}
 
-	for imgURL := range d.downloadQueue {
-		// Wait for the rate limiter
-		if err := d.limiter.Wait(context.Background()); err != nil {
-			time.Sleep(1 * time.Millisecond)
-			continue
+// release gives back the reservation of an image that failed to be stored and
+// deletes the files written so far
+func (d *DownloadResizer) release(pixelHash string, perceptualHash uint64, written []string) {
+	d.mtx.Lock()
+	d.reserved--
+	d.mtx.Unlock()
+	d.hashIndex.Remove(pixelHash, perceptualHash)
+	//ctx may be the reason the write failed, the cleanup must run anyway
+	for _, key := range written {
+		if rmErr := d.storage.Delete(context.Background(), key); rmErr != nil {
+			d.logger.Warning(rmErr.Error())
 		}
+	}
+}
 
-		d.downloadAndResizeImage(imgURL)
+// commit turns the reservation of a stored image into a saved image and
+// returns the new count, the run ends once the target count is reached
+func (d *DownloadResizer) commit() uint64 {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.reserved--
+	d.count++
+	if d.count == d.targetCount {
+		d.cancelCtx()
 	}
+	return d.count
 }
 
-func (d *DownloadResizer) downloadAndResizeImage(imageUrl string) (err error) {
-	filePath := fmt.Sprintf("%d.jpg", time.Now().UnixNano()+int64(d.rand.Intn(9999)))
-	fullPath := filepath.Join(d.saveDirectory, filePath)
+// fetch returns the bytes of an image url, data uris are decoded without any request
+func (d *DownloadResizer) fetch(imageURL string) ([]byte, error) {
+	if isDataURI(imageURL) {
+		_, data, err := decodeDataURI(imageURL)
+		if err != nil {
+			return nil, err
+		}
+		if err = d.filter.CheckSize(int64(len(data))); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	//only the request is throttled, decoding and storing the image happen after the host is released
+	release, err := d.imageHosts.Acquire(d.ctx, hostOf(imageURL))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if d.robots != nil {
+		if err = d.checkRobots(imageURL); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, 2*time.Second)
 	defer cancel()
 
 	client := &http.Client{}
 	if len(d.proxies) > 0 {
 		proxyURL, err := url.Parse(d.getRandomProxy())
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		client = &http.Client{
@@ -200,64 +624,56 @@ func (d *DownloadResizer) downloadAndResizeImage(imageUrl string) (err error) {
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", imageUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if d.polite != nil {
+		req.Header.Set("User-Agent", d.polite.UserAgent)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err = d.filter.CheckSize(resp.ContentLength); err != nil {
+		return nil, err
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(body))
+	body, err := io.ReadAll(io.LimitReader(resp.Body, d.maxBodySize()))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if err = d.filter.CheckSize(int64(len(body))); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
 
-	m := resize.Resize(imageWidth, 0, img, resize.Lanczos3)
+func (d *DownloadResizer) getRandomProxy() string {
+	return d.proxies[d.rand.Intn(len(d.proxies))]
+}
 
-	d.mtx.Lock()
-	defer d.mtx.Unlock()
-	if d.count >= d.targetCount {
-		return
-	}
-	out, err := os.Create(fullPath)
-	defer func() {
-		out.Close()
-		if err != nil {
-			if err = os.Remove(fullPath); err != nil {
-				d.logger.Warning(err.Error())
-			}
-		}
-	}()
+// checkRobots rejects imageURL when the robots.txt of its host disallows it
+func (d *DownloadResizer) checkRobots(imageURL string) error {
+	u, err := url.Parse(imageURL)
 	if err != nil {
 		return err
 	}
-	err = jpeg.Encode(out, m, nil)
+	allowed, err := d.robots.allowed(d.ctx, u)
 	if err != nil {
-		return err
+		return fmt.Errorf("getting robots.txt of %s: %w", u.Host, err)
 	}
-	d.count++
-	d.resultChan <- filePath
-	d.logger.Info(fmt.Sprintf("downloaded %d images", d.count))
-	if d.count == d.targetCount {
-		d.cancelCtx()
+	if !allowed {
+		return reject(RejectRobotsTxt, "robots.txt of %s disallows %s", u.Host, u.EscapedPath())
 	}
-
 	return nil
 }
 
-func (d *DownloadResizer) getRandomProxy() string {
-	return d.proxies[d.rand.Intn(len(d.proxies))]
-}
-
 func (d *DownloadResizer) fetchProxies() error {
 	var proxies []string
 	c := colly.NewCollector()
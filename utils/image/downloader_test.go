@@ -0,0 +1,215 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"scrapper/infrastructure/storage"
+	"scrapper/infrastructure/storage/filesystem"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(error)    {}
+func (nopLogger) Warning(string) {}
+func (nopLogger) Info(string)    {}
+
+// serverProvider scrapes the result pages of a local search server
+type serverProvider struct {
+	base string
+}
+
+func (p serverProvider) Name() string { return "local" }
+
+func (p serverProvider) BuildURL(query string, page int) string {
+	return fmt.Sprintf("%s/search?q=%s&page=%d", p.base, url.QueryEscape(query), page)
+}
+
+func (p serverProvider) Selector() string { return "img.result" }
+
+func (p serverProvider) Extract(e *colly.HTMLElement) []string {
+	return nonEmpty(e.Request.AbsoluteURL(e.Attr("src")))
+}
+
+// testImages are pictures far enough apart not to be near duplicates
+func testImages() []image.Image {
+	checker := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			if (x/8+y/8)%2 == 0 {
+				checker.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				checker.Set(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+	return []image.Image{gradient(64, 48, false), gradient(64, 48, true), checker}
+}
+
+func TestDownloadResizer_Download(t *testing.T) {
+	images := testImages()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		//only the first page has results
+		if r.URL.Query().Get("page") != "0" {
+			w.Write([]byte("<html><body></body></html>"))
+			return
+		}
+		page := "<html><body>"
+		for i := range images {
+			page += fmt.Sprintf(`<img class="result" src="/img/%d.png">`, i)
+		}
+		w.Write([]byte(page + `<img class="logo" src="/logo.png"></body></html>`))
+	})
+	mux.HandleFunc("/img/{n}", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		if _, err := fmt.Sscanf(r.PathValue("n"), "%d.png", &n); err != nil || n >= len(images) {
+			http.NotFound(w, r)
+			return
+		}
+		buf := &bytes.Buffer{}
+		png.Encode(buf, images[n])
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	st, err := filesystem.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := NewQueryList([]Query{{Text: "cats", Weight: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDownloadResizer(Config{
+		Storage:     st,
+		TargetCount: uint64(len(images)),
+		Queries:     queries,
+		Providers:   []SearchProvider{serverProvider{base: server.URL}},
+	}, nopLogger{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resultChan := make(chan DownloadResult, len(images))
+	go d.Download(ctx, resultChan)
+	results := make([]DownloadResult, 0, len(images))
+	for result := range resultChan {
+		results = append(results, result)
+	}
+
+	if ctx.Err() != nil {
+		t.Fatal("download didn't reach the target count in time")
+	}
+	if len(results) != len(images) {
+		t.Fatalf("downloaded %d images, must download %d", len(results), len(images))
+	}
+	for _, result := range results {
+		if result.SearchEngine != "local" || result.Query != "cats" || result.Width != imageWidth {
+			t.Errorf("result is %+v", result)
+		}
+		if ok, err := st.Exists(context.Background(), result.File); !ok || err != nil {
+			t.Errorf("file of %s exists:%t error:%v", result.SourceURL, ok, err)
+		}
+	}
+	if stats := d.Stats(); stats.Saved != uint64(len(images)) {
+		t.Errorf("stats are %s, must save %d", stats, len(images))
+	}
+}
+
+// blockingStorage holds every Put until release is closed, then fails them when fail is set
+type blockingStorage struct {
+	storage.Storage
+	mtx     sync.Mutex
+	started chan struct{}
+	release chan struct{}
+	fail    bool
+	deleted []string
+}
+
+func (s *blockingStorage) Name() string { return "blocking" }
+
+func (s *blockingStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	s.started <- struct{}{}
+	<-s.release
+	if s.fail {
+		return errors.New("upload failed")
+	}
+	return nil
+}
+
+func (s *blockingStorage) Delete(ctx context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func dataURI(t *testing.T, img image.Image) string {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDownloadResizer_StoresOutsideTheLock(t *testing.T) {
+	images := testImages()
+	for _, fail := range []bool{false, true} {
+		st := &blockingStorage{started: make(chan struct{}, len(images)), release: make(chan struct{}), fail: fail}
+		d := NewDownloadResizer(Config{Storage: st, TargetCount: 2}, nopLogger{})
+		d.ctx, d.cancelCtx = context.WithCancel(context.Background())
+		d.resultChan = make(chan DownloadResult, len(images))
+
+		errs := make(chan error, len(images))
+		for i := range images {
+			task := downloadTask{url: dataURI(t, images[i])}
+			go func() {
+				errs <- d.downloadAndResizeImage(task)
+			}()
+		}
+		//two images reserve the target count and upload at the same time, the third one is dropped
+		for i := 0; i < 2; i++ {
+			select {
+			case <-st.started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("uploads must not wait for each other")
+			}
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("error is %v, the image beyond the target count must be skipped", err)
+		}
+		close(st.release)
+		for i := 0; i < 2; i++ {
+			if err := <-errs; (err != nil) != fail {
+				t.Errorf("error is %v, must fail:%t", err, fail)
+			}
+		}
+
+		if fail {
+			//failed uploads give their reservation and hashes back
+			if d.count != 0 || d.reserved != 0 || d.hashIndex.Len() != 0 || len(st.deleted) != 2 {
+				t.Errorf("count:%d reserved:%d hashes:%d deleted:%v after failed uploads", d.count, d.reserved, d.hashIndex.Len(), st.deleted)
+			}
+			continue
+		}
+		if d.count != 2 || d.reserved != 0 || len(d.resultChan) != 2 || d.ctx.Err() == nil {
+			t.Errorf("count:%d reserved:%d results:%d, the run must end at the target count", d.count, d.reserved, len(d.resultChan))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package image
+
+type EventKind string
+
+const (
+	// EventDownloaded is sent once the source image is fetched
+	EventDownloaded EventKind = "downloaded"
+	// EventRejected is sent when the source image is filtered out or is a duplicate
+	EventRejected EventKind = "rejected"
+	// EventSaved is sent once every file of the image is stored
+	EventSaved EventKind = "saved"
+)
+
+// Event reports the progress of a download run for a single image
+type Event struct {
+	Kind         EventKind
+	SourceURL    string
+	SearchEngine string
+	Query        string
+	Reason       string //why the image was rejected
+	File         string //storage key of the main file of a saved image
+	Count        uint64 //images saved so far, set on EventSaved
+}
+
+func (d *DownloadResizer) emit(task downloadTask, e Event) {
+	if d.onEvent == nil {
+		return
+	}
+	e.SourceURL = sourceKey(task.url)
+	e.SearchEngine = task.engine
+	e.Query = task.query
+	d.onEvent(e)
+}
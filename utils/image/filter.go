@@ -0,0 +1,116 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// reasons an image is rejected by a Filter
+const (
+	RejectTooSmall     = "too-small"
+	RejectTooLarge     = "too-large"
+	RejectAspectRatio  = "aspect-ratio"
+	RejectTooManyBytes = "too-many-bytes"
+	RejectContentType  = "content-type"
+)
+
+var ErrRejected = errors.New("image rejected")
+
+// RejectedError tells why a downloaded image was rejected by the filter
+type RejectedError struct {
+	Reason string
+	Detail string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrRejected, e.Reason, e.Detail)
+}
+
+func (e *RejectedError) Unwrap() error {
+	return ErrRejected
+}
+
+func reject(reason, format string, args ...any) error {
+	return &RejectedError{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Filter rejects source images before they are decoded and stored, zero
+// values mean no limit
+type Filter struct {
+	MinWidth       int
+	MinHeight      int
+	MaxWidth       int
+	MaxHeight      int
+	MinAspectRatio float64 //width / height
+	MaxAspectRatio float64
+	MaxBytes       int64
+	// AllowedTypes are the accepted MIME types sniffed from the downloaded bytes, any type when empty
+	AllowedTypes []string
+}
+
+// DefaultFilter only accepts the formats the downloader can decode, up to 20MiB
+func DefaultFilter() Filter {
+	return Filter{
+		MaxBytes:     20 << 20,
+		AllowedTypes: []string{"image/jpeg", "image/png", "image/webp"},
+	}
+}
+
+func (f Filter) Validate() error {
+	if f.MinWidth < 0 || f.MinHeight < 0 || f.MaxWidth < 0 || f.MaxHeight < 0 || f.MaxBytes < 0 {
+		return errors.New("filter limits must not be negative")
+	}
+	if f.MaxWidth > 0 && f.MinWidth > f.MaxWidth {
+		return errors.New("filter min width is larger than max width")
+	}
+	if f.MaxHeight > 0 && f.MinHeight > f.MaxHeight {
+		return errors.New("filter min height is larger than max height")
+	}
+	if f.MinAspectRatio < 0 || f.MaxAspectRatio < 0 {
+		return errors.New("filter aspect ratios must not be negative")
+	}
+	if f.MaxAspectRatio > 0 && f.MinAspectRatio > f.MaxAspectRatio {
+		return errors.New("filter min aspect ratio is larger than max aspect ratio")
+	}
+	return nil
+}
+
+// CheckContentType rejects MIME types that are not allowed, parameters like charset are ignored
+func (f Filter) CheckContentType(contentType string) error {
+	if len(f.AllowedTypes) == 0 {
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, allowed := range f.AllowedTypes {
+		if strings.EqualFold(allowed, mediaType) {
+			return nil
+		}
+	}
+	return reject(RejectContentType, "%q is not allowed", mediaType)
+}
+
+func (f Filter) CheckSize(size int64) error {
+	if f.MaxBytes > 0 && size > f.MaxBytes {
+		return reject(RejectTooManyBytes, "more than %d bytes", f.MaxBytes)
+	}
+	return nil
+}
+
+func (f Filter) CheckDimensions(width, height int) error {
+	if width < f.MinWidth || height < f.MinHeight {
+		return reject(RejectTooSmall, "%dx%d is smaller than %dx%d", width, height, f.MinWidth, f.MinHeight)
+	}
+	if (f.MaxWidth > 0 && width > f.MaxWidth) || (f.MaxHeight > 0 && height > f.MaxHeight) {
+		return reject(RejectTooLarge, "%dx%d is larger than %dx%d", width, height, f.MaxWidth, f.MaxHeight)
+	}
+	if height == 0 {
+		return reject(RejectTooSmall, "%dx%d has no height", width, height)
+	}
+	ratio := float64(width) / float64(height)
+	if ratio < f.MinAspectRatio || (f.MaxAspectRatio > 0 && ratio > f.MaxAspectRatio) {
+		return reject(RejectAspectRatio, "%.2f is outside %.2f-%.2f", ratio, f.MinAspectRatio, f.MaxAspectRatio)
+	}
+	return nil
+}
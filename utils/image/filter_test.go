@@ -0,0 +1,147 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFilter_Validate(t *testing.T) {
+	var tests = []struct {
+		name    string
+		filter  Filter
+		mustErr bool
+	}{
+		{name: "zero", filter: Filter{}},
+		{name: "default", filter: DefaultFilter()},
+		{name: "limits", filter: Filter{MinWidth: 10, MaxWidth: 10, MinHeight: 5, MaxHeight: 50, MinAspectRatio: 0.5, MaxAspectRatio: 2}},
+		{name: "min width without max", filter: Filter{MinWidth: 5000}},
+		{name: "negative width", filter: Filter{MinWidth: -1}, mustErr: true},
+		{name: "negative bytes", filter: Filter{MaxBytes: -1}, mustErr: true},
+		{name: "min width above max", filter: Filter{MinWidth: 20, MaxWidth: 10}, mustErr: true},
+		{name: "min height above max", filter: Filter{MinHeight: 20, MaxHeight: 10}, mustErr: true},
+		{name: "negative aspect ratio", filter: Filter{MinAspectRatio: -1}, mustErr: true},
+		{name: "min aspect ratio above max", filter: Filter{MinAspectRatio: 2, MaxAspectRatio: 1}, mustErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.filter.Validate(); (err != nil) != test.mustErr {
+				t.Errorf("error is %v, must fail:%t", err, test.mustErr)
+			}
+		})
+	}
+}
+
+// mustReject checks that err rejects the image with reason, an empty reason
+// means the image is accepted
+func mustReject(t *testing.T, err error, reason string) {
+	t.Helper()
+	if reason == "" {
+		if err != nil {
+			t.Errorf("error is %v, must be accepted", err)
+		}
+		return
+	}
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || !errors.Is(err, ErrRejected) || rejected.Reason != reason {
+		t.Errorf("error is %v, must be rejected with %s", err, reason)
+	}
+}
+
+func TestFilter_CheckDimensions(t *testing.T) {
+	var tests = []struct {
+		name          string
+		filter        Filter
+		width, height int
+		mustReason    string
+	}{
+		{name: "no limits", width: 1, height: 1},
+		{name: "zero height", width: 10, height: 0, mustReason: RejectTooSmall},
+		{name: "narrower than min", filter: Filter{MinWidth: 100}, width: 99, height: 100, mustReason: RejectTooSmall},
+		{name: "lower than min", filter: Filter{MinHeight: 100}, width: 100, height: 99, mustReason: RejectTooSmall},
+		{name: "min size", filter: Filter{MinWidth: 100, MinHeight: 100}, width: 100, height: 100},
+		{name: "wider than max", filter: Filter{MaxWidth: 100}, width: 101, height: 10, mustReason: RejectTooLarge},
+		{name: "higher than max", filter: Filter{MaxHeight: 100}, width: 10, height: 101, mustReason: RejectTooLarge},
+		{name: "below min aspect ratio", filter: Filter{MinAspectRatio: 1}, width: 99, height: 100, mustReason: RejectAspectRatio},
+		{name: "at min aspect ratio", filter: Filter{MinAspectRatio: 1}, width: 100, height: 100},
+		{name: "above max aspect ratio", filter: Filter{MaxAspectRatio: 2}, width: 201, height: 100, mustReason: RejectAspectRatio},
+		{name: "at max aspect ratio", filter: Filter{MaxAspectRatio: 2}, width: 200, height: 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mustReject(t, test.filter.CheckDimensions(test.width, test.height), test.mustReason)
+		})
+	}
+}
+
+func TestFilter_CheckSize(t *testing.T) {
+	f := Filter{MaxBytes: 100}
+	mustReject(t, f.CheckSize(100), "")
+	mustReject(t, f.CheckSize(101), RejectTooManyBytes)
+	//an unknown content length is -1
+	mustReject(t, f.CheckSize(-1), "")
+	mustReject(t, Filter{}.CheckSize(1<<40), "")
+}
+
+func TestFilter_CheckContentType(t *testing.T) {
+	var tests = []struct {
+		filter      Filter
+		contentType string
+		mustReason  string
+	}{
+		{filter: DefaultFilter(), contentType: "image/jpeg"},
+		{filter: DefaultFilter(), contentType: "IMAGE/PNG; charset=binary"},
+		{filter: DefaultFilter(), contentType: "image/gif", mustReason: RejectContentType},
+		{filter: DefaultFilter(), contentType: "text/html; charset=utf-8", mustReason: RejectContentType},
+		{filter: DefaultFilter(), contentType: "", mustReason: RejectContentType},
+		{filter: DefaultFilter(), contentType: "application/octet-stream", mustReason: RejectContentType},
+		{filter: Filter{}, contentType: "application/octet-stream"},
+	}
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			mustReject(t, test.filter.CheckContentType(test.contentType), test.mustReason)
+		})
+	}
+}
+
+func TestDownloadResizer_CountResult(t *testing.T) {
+	events := make([]Event, 0)
+	d := NewDownloadResizer(Config{OnEvent: func(e Event) { events = append(events, e) }}, nopLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	d.ctx = ctx
+	task := downloadTask{url: "https://a/1.jpg", engine: "bing", query: "cats"}
+
+	for _, err := range []error{
+		nil,
+		reject(RejectTooSmall, "1x1"),
+		reject(RejectTooSmall, "2x2"),
+		fmt.Errorf("checking: %w", reject(RejectContentType, "text/html")),
+		ErrDuplicate,
+		ErrNearDuplicate,
+		errors.New("connection reset"),
+	} {
+		d.countResult(task, err)
+	}
+	//downloads aborted by the end of the run are not failures
+	cancel()
+	d.countResult(task, context.Canceled)
+
+	stats := d.Stats()
+	if stats.Rejected[RejectTooSmall] != 2 || stats.Rejected[RejectContentType] != 1 || stats.RejectedTotal() != 3 {
+		t.Errorf("rejected %v, must count 2 too small and 1 content type", stats.Rejected)
+	}
+	if stats.Duplicates != 2 || stats.Failed != 1 {
+		t.Errorf("duplicates:%d failed:%d, must be 2 and 1", stats.Duplicates, stats.Failed)
+	}
+	rejectedEvents := 0
+	for _, e := range events {
+		if e.Kind != EventRejected || e.SourceURL != task.url || e.SearchEngine != "bing" || e.Query != "cats" {
+			t.Errorf("event is %+v, must be a rejected event of the task", e)
+		}
+		rejectedEvents++
+	}
+	if rejectedEvents != 5 {
+		t.Errorf("%d rejected events, must be 5", rejectedEvents)
+	}
+}
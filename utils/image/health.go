@@ -0,0 +1,198 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// an engine that blocks a page is paused for minEngineBackoff, the pause
+// doubles with every block in a row up to maxEngineBackoff
+const (
+	minEngineBackoff = 30 * time.Second
+	maxEngineBackoff = 30 * time.Minute
+)
+
+// blockMarkers are parts of the url or the body of the CAPTCHA and consent
+// interstitials the search engines answer with instead of results
+var blockMarkers = []struct {
+	reason string
+	inURL  []string
+	inBody []string
+}{
+	{
+		reason: "captcha",
+		inURL:  []string{"google.com/sorry/", "showcaptcha", "/captcha"},
+		inBody: []string{"g-recaptcha", "h-captcha", "our systems have detected unusual traffic", "captcha-form", "smartcaptcha"},
+	},
+	{
+		reason: "consent page",
+		inURL:  []string{"consent.google.", "consent.yahoo.", "consent.youtube."},
+		inBody: []string{"before you continue to google", `action="https://consent.`},
+	},
+}
+
+// detectBlock reports why a search page response is not a result page but a
+// rate limit, CAPTCHA or consent page, with the wait the engine asked for
+func detectBlock(statusCode int, header http.Header, pageURL string, body []byte) (string, time.Duration, bool) {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return fmt.Sprintf("status %d", statusCode), retryAfter(header), true
+	}
+	pageURL = strings.ToLower(pageURL)
+	lowerBody := bytes.ToLower(body)
+	for _, marker := range blockMarkers {
+		for _, part := range marker.inURL {
+			if strings.Contains(pageURL, part) {
+				return marker.reason, retryAfter(header), true
+			}
+		}
+		for _, part := range marker.inBody {
+			if bytes.Contains(lowerBody, []byte(part)) {
+				return marker.reason, retryAfter(header), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an http date
+func retryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// EngineHealth tells how a search engine answered during a download run
+type EngineHealth struct {
+	Name        string
+	Pages       uint64 //result pages scraped
+	Blocked     uint64 //pages answered with a rate limit, CAPTCHA or consent page
+	LastReason  string //why the engine blocked the last time
+	PausedUntil time.Time
+}
+
+// Paused reports whether the engine was paused at now
+func (e EngineHealth) Paused(now time.Time) bool {
+	return now.Before(e.PausedUntil)
+}
+
+func (e EngineHealth) String() string {
+	state := "ok"
+	if wait := time.Until(e.PausedUntil); wait > 0 {
+		state = "paused " + wait.Round(time.Second).String()
+	}
+	s := fmt.Sprintf("%s %s, %d pages, %d blocked", e.Name, state, e.Pages, e.Blocked)
+	if e.LastReason != "" {
+		s += " (" + e.LastReason + ")"
+	}
+	return s
+}
+
+// engineHealth pauses the search engines that block the scraper with an
+// exponential backoff, so the pages are requested from the other engines meanwhile
+type engineHealth struct {
+	mtx     sync.Mutex
+	engines map[string]*engineState
+	now     func() time.Time
+}
+
+type engineState struct {
+	health  EngineHealth
+	strikes int //blocks in a row
+}
+
+func newEngineHealth(providers []SearchProvider) *engineHealth {
+	h := &engineHealth{engines: make(map[string]*engineState, len(providers)), now: time.Now}
+	for _, p := range providers {
+		h.engines[p.Name()] = &engineState{health: EngineHealth{Name: p.Name()}}
+	}
+	return h
+}
+
+func (h *engineHealth) state(engine string) *engineState {
+	s, ok := h.engines[engine]
+	if !ok {
+		s = &engineState{health: EngineHealth{Name: engine}}
+		h.engines[engine] = s
+	}
+	return s
+}
+
+// blocked pauses engine and returns for how long, the engine is paused at
+// least for the wait it asked for
+func (h *engineHealth) blocked(engine, reason string, wait time.Duration) time.Duration {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	s := h.state(engine)
+	backoff := minEngineBackoff
+	for i := 0; i < s.strikes && backoff < maxEngineBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = max(min(backoff, maxEngineBackoff), wait)
+	s.strikes++
+	s.health.Blocked++
+	s.health.LastReason = reason
+	s.health.PausedUntil = h.now().Add(backoff)
+	return backoff
+}
+
+// ok records a scraped page and reports whether the engine recovered from blocks
+func (h *engineHealth) ok(engine string) bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	s := h.state(engine)
+	s.health.Pages++
+	recovered := s.strikes > 0
+	s.strikes = 0
+	return recovered
+}
+
+func (h *engineHealth) available(provider SearchProvider) bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return !h.state(provider.Name()).health.Paused(h.now())
+}
+
+// untilResume returns the time until the first paused engine is resumed
+func (h *engineHealth) untilResume() time.Duration {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	now := h.now()
+	var wait time.Duration
+	for _, s := range h.engines {
+		if d := s.health.PausedUntil.Sub(now); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (h *engineHealth) snapshot() []EngineHealth {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	engines := make([]EngineHealth, 0, len(h.engines))
+	for _, s := range h.engines {
+		engines = append(engines, s.health)
+	}
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i].Name < engines[j].Name
+	})
+	return engines
+}
@@ -0,0 +1,150 @@
+package image
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDetectBlock(t *testing.T) {
+	var tests = []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		pageURL    string
+		body       string
+		mustReason string
+		mustWait   time.Duration
+	}{
+		{
+			name:       "too many requests with retry after",
+			statusCode: http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": []string{"120"}},
+			pageURL:    "https://www.bing.com/images/search?q=cats",
+			mustReason: "status 429",
+			mustWait:   2 * time.Minute,
+		},
+		{
+			name:       "service unavailable",
+			statusCode: http.StatusServiceUnavailable,
+			pageURL:    "https://www.google.com/search?q=cats",
+			mustReason: "status 503",
+		},
+		{
+			name:       "redirected to the sorry page",
+			statusCode: http.StatusOK,
+			pageURL:    "https://www.google.com/sorry/index?continue=https://www.google.com/search",
+			mustReason: "captcha",
+		},
+		{
+			name:       "recaptcha in the body",
+			statusCode: http.StatusOK,
+			pageURL:    "https://duckduckgo.com/?q=cats",
+			body:       `<div class="g-recaptcha" data-sitekey="x"></div>`,
+			mustReason: "captcha",
+		},
+		{
+			name:       "consent page",
+			statusCode: http.StatusOK,
+			pageURL:    "https://consent.google.com/ml?continue=https://www.google.com/search",
+			mustReason: "consent page",
+		},
+		{
+			name:       "result page",
+			statusCode: http.StatusOK,
+			pageURL:    "https://www.bing.com/images/search?q=cats",
+			body:       `<a class="iusc" m='{"murl":"https://a/1.jpg"}'></a>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason, wait, ok := detectBlock(test.statusCode, test.header, test.pageURL, []byte(test.body))
+			if ok != (test.mustReason != "") || reason != test.mustReason {
+				t.Errorf("reason:%q ok:%t, must be %q", reason, ok, test.mustReason)
+			}
+			if wait != test.mustWait {
+				t.Errorf("wait:%s is not equal to:%s", wait, test.mustWait)
+			}
+		})
+	}
+}
+
+func TestEngineHealth_Backoff(t *testing.T) {
+	provider := bingProvider{}
+	h := newEngineHealth([]SearchProvider{provider})
+	now := time.Now()
+	h.now = func() time.Time { return now }
+
+	//every block in a row doubles the pause up to maxEngineBackoff
+	for i, must := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		if pause := h.blocked(provider.Name(), "captcha", 0); pause != must {
+			t.Errorf("block %d paused for %s, must pause for %s", i+1, pause, must)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		h.blocked(provider.Name(), "captcha", 0)
+	}
+	if pause := h.blocked(provider.Name(), "captcha", 0); pause != maxEngineBackoff {
+		t.Errorf("paused for %s, must not pause longer than %s", pause, maxEngineBackoff)
+	}
+	if h.available(provider) {
+		t.Error("a paused engine must not be available")
+	}
+	if wait := h.untilResume(); wait != maxEngineBackoff {
+		t.Errorf("resuming in %s, must resume in %s", wait, maxEngineBackoff)
+	}
+
+	now = now.Add(maxEngineBackoff)
+	if !h.available(provider) {
+		t.Error("the engine must be available once the pause is over")
+	}
+	if !h.ok(provider.Name()) {
+		t.Error("a scraped page after blocks must report the engine recovered")
+	}
+	if h.ok(provider.Name()) {
+		t.Error("a healthy engine must not report it recovered")
+	}
+	//the backoff starts over after a scraped page, the wait the engine asked for is kept
+	if pause := h.blocked(provider.Name(), "status 429", time.Hour); pause != time.Hour {
+		t.Errorf("paused for %s, must pause for the retry after of 1h", pause)
+	}
+	if pause := h.blocked(provider.Name(), "status 429", 0); pause != time.Minute {
+		t.Errorf("paused for %s, must pause for 1m", pause)
+	}
+
+	engines := h.snapshot()
+	if len(engines) != 1 || engines[0].Pages != 2 || engines[0].Blocked != 17 || engines[0].LastReason != "status 429" {
+		t.Errorf("engines are %+v", engines)
+	}
+}
+
+func TestQueryCursors_SkipPausedEngines(t *testing.T) {
+	queries, err := NewQueryList([]Query{{Text: "cats", Weight: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paused, healthy := bingProvider{}, duckDuckGoProvider{}
+	cursors := newQueryCursors([]SearchProvider{paused, healthy}, queries)
+	usable := func(p SearchProvider) bool { return p.Name() != paused.Name() }
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		provider, _, _, ok := cursors.next(r, usable)
+		if !ok || provider.Name() != healthy.Name() {
+			t.Fatalf("next returned %v ok:%t, must return the engine that is not paused", provider, ok)
+		}
+	}
+
+	//the paused engine still has queries, so the cursors are not exhausted
+	for i := 0; i < 10 && !cursors.get(healthy, "cats").dry; i++ {
+		cursors.done(healthy, "cats", 0, 0, false)
+	}
+	if _, _, _, ok := cursors.next(r, usable); ok {
+		t.Error("next must not return a paused engine")
+	}
+	if cursors.exhausted() {
+		t.Error("cursors with a paused engine left must not be exhausted")
+	}
+}
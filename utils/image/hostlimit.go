@@ -0,0 +1,158 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrInvalidHostLimits = errors.New("invalid host limits")
+
+// HostLimits throttles the requests sent to a single host
+type HostLimits struct {
+	// Rate is the number of requests per second sent to a host
+	Rate float64
+	// Burst is the number of requests sent to an idle host at once
+	Burst int
+	// MaxInFlight is the number of requests to a host running at the same time, 0 is unlimited
+	MaxInFlight int
+	// IdleTimeout is the time after which the limiter of a host without requests is dropped
+	IdleTimeout time.Duration
+}
+
+// DefaultImageHostLimits are the limits of every image host
+func DefaultImageHostLimits() HostLimits {
+	return HostLimits{Rate: 20, Burst: 40, MaxInFlight: 32, IdleTimeout: 5 * time.Minute}
+}
+
+// DefaultSearchHostLimits are the limits of the search engines, they block
+// scrapers much sooner than image hosts
+func DefaultSearchHostLimits() HostLimits {
+	return HostLimits{Rate: 1, Burst: 2, MaxInFlight: 2, IdleTimeout: 10 * time.Minute}
+}
+
+func (l HostLimits) Validate() error {
+	if l.Rate <= 0 {
+		return fmt.Errorf("%w: rate must be greater than zero", ErrInvalidHostLimits)
+	}
+	if l.Burst <= 0 {
+		return fmt.Errorf("%w: burst must be greater than zero", ErrInvalidHostLimits)
+	}
+	if l.MaxInFlight < 0 || l.IdleTimeout < 0 {
+		return fmt.Errorf("%w: max in flight and idle timeout can't be negative", ErrInvalidHostLimits)
+	}
+	return nil
+}
+
+// HostLimiter applies HostLimits to every host separately, so a slow or
+// throttled host doesn't hold back the requests to the others
+type HostLimiter struct {
+	limits    HostLimits
+	mtx       *sync.Mutex
+	hosts     map[string]*hostLimit
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type hostLimit struct {
+	limiter  *rate.Limiter
+	slots    chan struct{} //nil when the in flight requests are unlimited
+	users    int           //requests waiting or running, the limit is kept while there are any
+	lastUsed time.Time
+}
+
+func NewHostLimiter(limits HostLimits) *HostLimiter {
+	return &HostLimiter{
+		limits:    limits,
+		mtx:       &sync.Mutex{},
+		hosts:     make(map[string]*hostLimit),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Acquire waits until a request may be sent to host, the returned func must be
+// called once the request is done
+func (h *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	hl := h.use(host)
+	if hl.slots != nil {
+		select {
+		case hl.slots <- struct{}{}:
+		case <-ctx.Done():
+			h.done(hl, false)
+			return nil, ctx.Err()
+		}
+	}
+	if err := hl.limiter.Wait(ctx); err != nil {
+		h.done(hl, true)
+		return nil, err
+	}
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() { h.done(hl, true) })
+	}, nil
+}
+
+func (h *HostLimiter) use(host string) *hostLimit {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	now := h.now()
+	if h.limits.IdleTimeout > 0 && now.Sub(h.lastSweep) >= h.limits.IdleTimeout {
+		h.sweep(now)
+	}
+	hl, ok := h.hosts[host]
+	if !ok {
+		hl = &hostLimit{limiter: rate.NewLimiter(rate.Limit(h.limits.Rate), h.limits.Burst)}
+		if h.limits.MaxInFlight > 0 {
+			hl.slots = make(chan struct{}, h.limits.MaxInFlight)
+		}
+		h.hosts[host] = hl
+	}
+	hl.users++
+	hl.lastUsed = now
+	return hl
+}
+
+func (h *HostLimiter) done(hl *hostLimit, holdsSlot bool) {
+	if holdsSlot && hl.slots != nil {
+		<-hl.slots
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	hl.users--
+	hl.lastUsed = h.now()
+}
+
+// sweep drops the limits of the hosts idle for IdleTimeout, a dropped host
+// starts over with a full burst
+func (h *HostLimiter) sweep(now time.Time) {
+	for host, hl := range h.hosts {
+		if hl.users == 0 && now.Sub(hl.lastUsed) >= h.limits.IdleTimeout {
+			delete(h.hosts, host)
+		}
+	}
+	h.lastSweep = now
+}
+
+// Len returns the number of hosts with a limiter
+func (h *HostLimiter) Len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.hosts)
+}
+
+// hostOf returns the lower cased host of rawURL, the host limits of urls
+// without one are shared
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,122 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter_MaxInFlight(t *testing.T) {
+	h := NewHostLimiter(HostLimits{Rate: 1000, Burst: 10, MaxInFlight: 2})
+	ctx := context.Background()
+	releases := make([]func(), 0, 2)
+	for i := 0; i < 2; i++ {
+		release, err := h.Acquire(ctx, "a.example")
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+
+	//the third request to the host waits for a slot, other hosts don't
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := h.Acquire(timeoutCtx, "a.example"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error is %v, must wait until the deadline", err)
+	}
+	release, err := h.Acquire(ctx, "b.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := h.Acquire(ctx, "a.example")
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	releases[0]()
+	releases[0]() //releasing twice must not free a second slot
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a released slot must be handed to the waiting request")
+	}
+	releases[1]()
+}
+
+func TestHostLimiter_Rate(t *testing.T) {
+	h := NewHostLimiter(HostLimits{Rate: 20, Burst: 1})
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := h.Acquire(ctx, "a.example")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	//the first request uses the burst, the other two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s with a burst of 1 took %s, must take 100ms", elapsed)
+	}
+	start = time.Now()
+	release, err := h.Acquire(ctx, "b.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("the first request to another host took %s, must not wait", elapsed)
+	}
+}
+
+func TestHostLimiter_EvictsIdleHosts(t *testing.T) {
+	h := NewHostLimiter(HostLimits{Rate: 1000, Burst: 10, MaxInFlight: 1, IdleTimeout: time.Minute})
+	now := time.Now()
+	h.now = func() time.Time { return now }
+	h.lastSweep = now
+	ctx := context.Background()
+
+	idle, err := h.Acquire(ctx, "idle.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle()
+	busy, err := h.Acquire(ctx, "busy.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy()
+
+	now = now.Add(2 * time.Minute)
+	release, err := h.Acquire(ctx, "other.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	//the idle host is dropped, the host with a request in flight is kept
+	if n := h.Len(); n != 2 {
+		t.Errorf("%d hosts have a limiter, must be 2", n)
+	}
+}
+
+func TestHostLimits_Validate(t *testing.T) {
+	for _, limits := range []HostLimits{{}, {Rate: 1}, {Rate: 1, Burst: 1, MaxInFlight: -1}} {
+		if err := limits.Validate(); !errors.Is(err, ErrInvalidHostLimits) {
+			t.Errorf("%+v: error is %v, must be ErrInvalidHostLimits", limits, err)
+		}
+	}
+	for _, limits := range []HostLimits{DefaultImageHostLimits(), DefaultSearchHostLimits()} {
+		if err := limits.Validate(); err != nil {
+			t.Errorf("%+v: %s", limits, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package image
+
+import "sync"
+
+// hostQueues queues the download tasks of every host separately and drains
+// each queue with at most maxPerHost workers, so a slow or throttled host only
+// ties up its own workers while the tasks of the other hosts keep running
+type hostQueues struct {
+	mtx        *sync.Mutex
+	queues     map[string]*hostQueue
+	maxPerHost int
+	slots      chan struct{} //a slot for every running worker of all hosts
+	queued     chan struct{} //a slot for every queued task, push blocks once it is full
+	run        func(downloadTask)
+	workers    *sync.WaitGroup
+}
+
+type hostQueue struct {
+	tasks   []downloadTask
+	workers int
+}
+
+// newHostQueues runs every pushed task with run, maxPerHost of zero lets a
+// host use all maxWorkers workers
+func newHostQueues(maxPerHost, maxWorkers, capacity int, run func(downloadTask)) *hostQueues {
+	if maxPerHost <= 0 || maxPerHost > maxWorkers {
+		maxPerHost = maxWorkers
+	}
+	return &hostQueues{
+		mtx:        &sync.Mutex{},
+		queues:     make(map[string]*hostQueue),
+		maxPerHost: maxPerHost,
+		slots:      make(chan struct{}, maxWorkers),
+		queued:     make(chan struct{}, capacity),
+		run:        run,
+		workers:    &sync.WaitGroup{},
+	}
+}
+
+// push queues task behind the other tasks of its host and starts a worker for
+// the host unless it has maxPerHost already
+func (q *hostQueues) push(task downloadTask) {
+	q.queued <- struct{}{}
+	host := hostOf(task.url)
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	hq, ok := q.queues[host]
+	if !ok {
+		hq = &hostQueue{}
+		q.queues[host] = hq
+	}
+	hq.tasks = append(hq.tasks, task)
+	if hq.workers < q.maxPerHost {
+		hq.workers++
+		q.workers.Add(1)
+		go q.drain(host, hq)
+	}
+}
+
+// drain runs the tasks of host until its queue is empty
+func (q *hostQueues) drain(host string, hq *hostQueue) {
+	defer q.workers.Done()
+	q.slots <- struct{}{}
+	defer func() { <-q.slots }()
+	for {
+		q.mtx.Lock()
+		if len(hq.tasks) == 0 {
+			hq.workers--
+			if hq.workers == 0 {
+				delete(q.queues, host)
+			}
+			q.mtx.Unlock()
+			return
+		}
+		task := hq.tasks[0]
+		hq.tasks[0] = downloadTask{}
+		hq.tasks = hq.tasks[1:]
+		q.mtx.Unlock()
+		<-q.queued
+		q.run(task)
+	}
+}
+
+// wait returns once every pushed task ran, push must not be called meanwhile
+func (q *hostQueues) wait() {
+	q.workers.Wait()
+}
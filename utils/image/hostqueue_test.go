@@ -0,0 +1,82 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostQueues_StalledHost(t *testing.T) {
+	limits := HostLimits{Rate: 1000, Burst: 1000, MaxInFlight: 2}
+	hosts := NewHostLimiter(limits)
+	stall := make(chan struct{})
+	fastDone := make(chan string, 10)
+	var slowRunning, maxSlowRunning atomic.Int64
+	q := newHostQueues(limits.MaxInFlight, 4, 100, func(task downloadTask) {
+		release, err := hosts.Acquire(context.Background(), hostOf(task.url))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer release()
+		if hostOf(task.url) != "slow.example" {
+			fastDone <- task.url
+			return
+		}
+		running := slowRunning.Add(1)
+		defer slowRunning.Add(-1)
+		for {
+			max := maxSlowRunning.Load()
+			if running <= max || maxSlowRunning.CompareAndSwap(max, running) {
+				break
+			}
+		}
+		<-stall
+	})
+
+	//the slow host has more tasks than there are workers
+	for i := 0; i < 20; i++ {
+		q.push(downloadTask{url: fmt.Sprintf("https://slow.example/%d.jpg", i)})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for slowRunning.Load() < int64(limits.MaxInFlight) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	//the slow host is stalled with its in flight requests, the other host still gets workers
+	for i := 0; i < 3; i++ {
+		q.push(downloadTask{url: fmt.Sprintf("https://fast.example/%d.jpg", i)})
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-fastDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a stalled host must not hold back the other hosts")
+		}
+	}
+	if max := maxSlowRunning.Load(); max != int64(limits.MaxInFlight) {
+		t.Errorf("the slow host ran %d tasks at once, must run %d", max, limits.MaxInFlight)
+	}
+	close(stall)
+	q.wait()
+}
+
+func TestHostQueues_RunsEveryTask(t *testing.T) {
+	var ran atomic.Int64
+	q := newHostQueues(0, 3, 5, func(task downloadTask) {
+		ran.Add(1)
+	})
+	//more tasks than the capacity, push waits for the workers
+	for i := 0; i < 50; i++ {
+		q.push(downloadTask{url: fmt.Sprintf("https://host%d.example/%d.jpg", i%4, i)})
+	}
+	q.wait()
+	if ran.Load() != 50 {
+		t.Errorf("ran %d tasks, must run 50", ran.Load())
+	}
+	if len(q.queues) != 0 {
+		t.Errorf("%d host queues left, the drained queues must be dropped", len(q.queues))
+	}
+}
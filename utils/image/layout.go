@@ -0,0 +1,51 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// sha256HexLen is the length of the hex encoded content hashes files are named after
+const sha256HexLen = 64
+
+var ErrInvalidLayout = errors.New("invalid storage layout")
+
+// Layout names stored files after the hash of their content and shards them
+// into nested directories, e.g. "ab/cd/abcdef....jpg" with 2 levels of width 2
+type Layout struct {
+	// ShardLevels is the number of nested directories, 0 stores every file in the root
+	ShardLevels int
+	// ShardWidth is the number of hash characters naming each directory level
+	ShardWidth int
+}
+
+// DefaultLayout shards the files into 65536 directories of two levels
+func DefaultLayout() Layout {
+	return Layout{ShardLevels: 2, ShardWidth: 2}
+}
+
+func (l Layout) Validate() error {
+	if l.ShardLevels < 0 || l.ShardWidth < 0 {
+		return fmt.Errorf("%w: shard levels and width can't be negative", ErrInvalidLayout)
+	}
+	if l.ShardLevels > 0 && l.ShardWidth == 0 {
+		return fmt.Errorf("%w: shard width must be greater than zero", ErrInvalidLayout)
+	}
+	if l.ShardLevels*l.ShardWidth >= sha256HexLen {
+		return fmt.Errorf("%w: %d levels of %d characters use up the whole hash", ErrInvalidLayout, l.ShardLevels, l.ShardWidth)
+	}
+	return nil
+}
+
+// Key returns the storage key of a file with the hex encoded sha256 sum and
+// extension ext, including its dot
+func (l Layout) Key(sum, ext string) string {
+	sum = strings.ToLower(sum)
+	dirs := make([]string, 0, l.ShardLevels+1)
+	for i := 0; i < l.ShardLevels && (i+1)*l.ShardWidth <= len(sum); i++ {
+		dirs = append(dirs, sum[i*l.ShardWidth:(i+1)*l.ShardWidth])
+	}
+	return path.Join(append(dirs, sum+ext)...)
+}
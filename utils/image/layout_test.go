@@ -0,0 +1,50 @@
+package image
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLayout_Key(t *testing.T) {
+	sum := "ABCDEF" + strings.Repeat("0", 58)
+	var tests = []struct {
+		name    string
+		layout  Layout
+		mustKey string
+	}{
+		{
+			name:    "default layout shards two levels",
+			layout:  DefaultLayout(),
+			mustKey: "ab/cd/" + strings.ToLower(sum) + ".jpg",
+		},
+		{
+			name:    "flat layout",
+			layout:  Layout{},
+			mustKey: strings.ToLower(sum) + ".jpg",
+		},
+		{
+			name:    "wide shards",
+			layout:  Layout{ShardLevels: 1, ShardWidth: 3},
+			mustKey: "abc/" + strings.ToLower(sum) + ".jpg",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.layout.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if key := test.layout.Key(sum, ".jpg"); key != test.mustKey {
+				t.Errorf("key is %s, must be %s", key, test.mustKey)
+			}
+		})
+	}
+}
+
+func TestLayout_Validate(t *testing.T) {
+	for _, layout := range []Layout{{ShardLevels: -1}, {ShardLevels: 2}, {ShardLevels: 32, ShardWidth: 2}} {
+		if err := layout.Validate(); !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("%+v: error is %v, must be ErrInvalidLayout", layout, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/nfnt/resize"
+	_ "golang.org/x/image/webp" //register the webp decoder for downloaded images
+)
+
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp" //lossless, quality is ignored
+)
+
+type ResizeMode string
+
+const (
+	ResizeFitWidth  ResizeMode = "fit-width"  //scale to Width keeping the aspect ratio
+	ResizeFitHeight ResizeMode = "fit-height" //scale to Height keeping the aspect ratio
+	ResizeFit       ResizeMode = "fit"        //scale down to fit inside Width x Height keeping the aspect ratio
+	ResizeFill      ResizeMode = "fill"       //scale to cover Width x Height and crop the overflow around the center
+	ResizeNone      ResizeMode = "none"       //keep the original size
+)
+
+var ErrInvalidVariant = errors.New("invalid output variant")
+
+// Variant is one stored output of every downloaded image
+type Variant struct {
+	Name    string
+	Format  Format
+	Quality int //jpeg quality from 1 to 100
+	Mode    ResizeMode
+	Width   uint
+	Height  uint
+}
+
+// DefaultVariant is a 100px wide jpeg, the output of the downloader before variants existed
+func DefaultVariant() Variant {
+	return Variant{
+		Name:    "default",
+		Format:  FormatJPEG,
+		Quality: jpeg.DefaultQuality,
+		Mode:    ResizeFitWidth,
+		Width:   imageWidth,
+	}
+}
+
+// ParseVariant parses a "key=value,..." spec, e.g.
+// "name=thumb,format=webp,mode=fill,width=150,height=150". Missing keys are
+// taken from DefaultVariant, and fill without a height crops to a square
+func ParseVariant(spec string) (Variant, error) {
+	v := DefaultVariant()
+	v.Name = ""
+	heightSet := false
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return Variant{}, fmt.Errorf("%w: %q is not key=value", ErrInvalidVariant, pair)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.TrimSpace(key) {
+		case "name":
+			v.Name = value
+		case "format":
+			v.Format = Format(strings.ToLower(value))
+		case "quality":
+			v.Quality, err = strconv.Atoi(value)
+		case "mode":
+			v.Mode = ResizeMode(strings.ToLower(value))
+		case "width":
+			v.Width, err = parseUint(value)
+		case "height":
+			v.Height, err = parseUint(value)
+			heightSet = true
+		default:
+			return Variant{}, fmt.Errorf("%w: unknown key %q", ErrInvalidVariant, key)
+		}
+		if err != nil {
+			return Variant{}, fmt.Errorf("%w: %s: %s", ErrInvalidVariant, key, err)
+		}
+	}
+	if v.Mode == ResizeFill && !heightSet {
+		v.Height = v.Width
+	}
+	if v.Name == "" {
+		v.Name = fmt.Sprintf("%s_%s_%dx%d", v.Format, v.Mode, v.Width, v.Height)
+	}
+	return v, v.Validate()
+}
+
+func parseUint(value string) (uint, error) {
+	n, err := strconv.ParseUint(value, 10, 32)
+	return uint(n), err
+}
+
+func (v Variant) Validate() error {
+	if v.Name == "" || strings.ContainsAny(v.Name, `/\. `) {
+		return fmt.Errorf("%w: name %q must be non empty without dots, slashes or spaces", ErrInvalidVariant, v.Name)
+	}
+	switch v.Format {
+	case FormatJPEG:
+		if v.Quality < 1 || v.Quality > 100 {
+			return fmt.Errorf("%w: %s: jpeg quality must be between 1 and 100", ErrInvalidVariant, v.Name)
+		}
+	case FormatPNG, FormatWebP:
+	default:
+		return fmt.Errorf("%w: %s: unknown format %q", ErrInvalidVariant, v.Name, v.Format)
+	}
+	switch v.Mode {
+	case ResizeFitWidth:
+		if v.Width == 0 {
+			return fmt.Errorf("%w: %s: %s needs a width", ErrInvalidVariant, v.Name, v.Mode)
+		}
+	case ResizeFitHeight:
+		if v.Height == 0 {
+			return fmt.Errorf("%w: %s: %s needs a height", ErrInvalidVariant, v.Name, v.Mode)
+		}
+	case ResizeFit, ResizeFill:
+		if v.Width == 0 || v.Height == 0 {
+			return fmt.Errorf("%w: %s: %s needs a width and a height", ErrInvalidVariant, v.Name, v.Mode)
+		}
+	case ResizeNone:
+	default:
+		return fmt.Errorf("%w: %s: unknown resize mode %q", ErrInvalidVariant, v.Name, v.Mode)
+	}
+	return nil
+}
+
+// ValidateVariants checks every variant and that their names are unique
+func ValidateVariants(variants []Variant) error {
+	if len(variants) == 0 {
+		return fmt.Errorf("%w: at least one variant is needed", ErrInvalidVariant)
+	}
+	names := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+		if names[v.Name] {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidVariant, v.Name)
+		}
+		names[v.Name] = true
+	}
+	return nil
+}
+
+func (v Variant) Resize(img image.Image) image.Image {
+	switch v.Mode {
+	case ResizeFitWidth:
+		return resize.Resize(v.Width, 0, img, resize.Lanczos3)
+	case ResizeFitHeight:
+		return resize.Resize(0, v.Height, img, resize.Lanczos3)
+	case ResizeFit:
+		return resize.Thumbnail(v.Width, v.Height, img, resize.Lanczos3)
+	case ResizeFill:
+		return fill(img, v.Width, v.Height)
+	default:
+		return img
+	}
+}
+
+// fill scales img to cover width x height and crops the overflow around the center
+func fill(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	srcW, srcH := uint(b.Dx()), uint(b.Dy())
+	//scale along the side that needs the larger factor so the other one overflows
+	var scaled image.Image
+	if srcW*height > srcH*width {
+		scaled = resize.Resize(0, height, img, resize.Lanczos3)
+	} else {
+		scaled = resize.Resize(width, 0, img, resize.Lanczos3)
+	}
+	sb := scaled.Bounds()
+	x := sb.Min.X + (sb.Dx()-int(width))/2
+	y := sb.Min.Y + (sb.Dy()-int(height))/2
+	crop := image.Rect(x, y, x+int(width), y+int(height)).Intersect(sb)
+	if sub, ok := scaled.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(crop)
+	}
+	return scaled
+}
+
+func (v Variant) Encode(w io.Writer, img image.Image) error {
+	switch v.Format {
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: v.Quality})
+	}
+}
+
+func (v Variant) ContentType() string {
+	return "image/" + string(v.Format)
+}
+
+func (v Variant) Extension() string {
+	if v.Format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(v.Format)
+}
@@ -0,0 +1,173 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseVariant(t *testing.T) {
+	var tests = []struct {
+		spec        string
+		mustVariant Variant
+		mustErr     bool
+	}{
+		{
+			spec:        "name=thumb,format=webp,mode=fill,width=150,height=100",
+			mustVariant: Variant{Name: "thumb", Format: FormatWebP, Quality: 75, Mode: ResizeFill, Width: 150, Height: 100},
+		},
+		{
+			spec:        "mode=fill,width=64",
+			mustVariant: Variant{Name: "jpeg_fill_64x64", Format: FormatJPEG, Quality: 75, Mode: ResizeFill, Width: 64, Height: 64},
+		},
+		{
+			spec:        " name = big , format = PNG , mode = none ",
+			mustVariant: Variant{Name: "big", Format: FormatPNG, Quality: 75, Mode: ResizeNone, Width: imageWidth},
+		},
+		{
+			spec:        "quality=90",
+			mustVariant: Variant{Name: "jpeg_fit-width_100x0", Format: FormatJPEG, Quality: 90, Mode: ResizeFitWidth, Width: imageWidth},
+		},
+		{spec: "name=thumb,size=3", mustErr: true},
+		{spec: "name", mustErr: true},
+		{spec: "width=-1", mustErr: true},
+		{spec: "quality=high", mustErr: true},
+		{spec: "format=gif", mustErr: true},
+		{spec: "name=a.b", mustErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			v, err := ParseVariant(test.spec)
+			if test.mustErr {
+				if !errors.Is(err, ErrInvalidVariant) {
+					t.Errorf("error is %v, must be ErrInvalidVariant", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != test.mustVariant {
+				t.Errorf("variant is %+v, must be %+v", v, test.mustVariant)
+			}
+		})
+	}
+}
+
+func TestVariant_Validate(t *testing.T) {
+	valid := DefaultVariant()
+	var tests = []struct {
+		name    string
+		change  func(*Variant)
+		mustErr bool
+	}{
+		{name: "default", change: func(v *Variant) {}},
+		{name: "empty name", change: func(v *Variant) { v.Name = "" }, mustErr: true},
+		{name: "name with a slash", change: func(v *Variant) { v.Name = "a/b" }, mustErr: true},
+		{name: "jpeg quality 0", change: func(v *Variant) { v.Quality = 0 }, mustErr: true},
+		{name: "jpeg quality 101", change: func(v *Variant) { v.Quality = 101 }, mustErr: true},
+		{name: "png ignores quality", change: func(v *Variant) { v.Format, v.Quality = FormatPNG, 0 }},
+		{name: "unknown format", change: func(v *Variant) { v.Format = "gif" }, mustErr: true},
+		{name: "fit width without width", change: func(v *Variant) { v.Width = 0 }, mustErr: true},
+		{name: "fit height without height", change: func(v *Variant) { v.Mode = ResizeFitHeight }, mustErr: true},
+		{name: "fit without height", change: func(v *Variant) { v.Mode = ResizeFit }, mustErr: true},
+		{name: "fill without width", change: func(v *Variant) { v.Mode, v.Width, v.Height = ResizeFill, 0, 10 }, mustErr: true},
+		{name: "none without size", change: func(v *Variant) { v.Mode, v.Width = ResizeNone, 0 }},
+		{name: "unknown mode", change: func(v *Variant) { v.Mode = "stretch" }, mustErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := valid
+			test.change(&v)
+			err := v.Validate()
+			if (err != nil) != test.mustErr || (err != nil && !errors.Is(err, ErrInvalidVariant)) {
+				t.Errorf("error is %v, must fail:%t", err, test.mustErr)
+			}
+		})
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	thumb := Variant{Name: "thumb", Format: FormatPNG, Mode: ResizeFill, Width: 10, Height: 10}
+	for _, variants := range [][]Variant{nil, {DefaultVariant(), DefaultVariant()}, {thumb, {Name: "bad"}}} {
+		if err := ValidateVariants(variants); !errors.Is(err, ErrInvalidVariant) {
+			t.Errorf("%+v: error is %v, must be ErrInvalidVariant", variants, err)
+		}
+	}
+	if err := ValidateVariants([]Variant{DefaultVariant(), thumb}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVariant_Resize(t *testing.T) {
+	//a 200x100 image that is red on the left half and blue on the right half
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			if x < 100 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var tests = []struct {
+		variant    Variant
+		mustWidth  int
+		mustHeight int
+	}{
+		{variant: Variant{Mode: ResizeFitWidth, Width: 50}, mustWidth: 50, mustHeight: 25},
+		{variant: Variant{Mode: ResizeFitHeight, Height: 50}, mustWidth: 100, mustHeight: 50},
+		{variant: Variant{Mode: ResizeFit, Width: 60, Height: 60}, mustWidth: 60, mustHeight: 30},
+		{variant: Variant{Mode: ResizeFit, Width: 400, Height: 400}, mustWidth: 200, mustHeight: 100},
+		{variant: Variant{Mode: ResizeFill, Width: 40, Height: 40}, mustWidth: 40, mustHeight: 40},
+		{variant: Variant{Mode: ResizeFill, Width: 100, Height: 10}, mustWidth: 100, mustHeight: 10},
+		{variant: Variant{Mode: ResizeNone}, mustWidth: 200, mustHeight: 100},
+	}
+	for _, test := range tests {
+		t.Run(string(test.variant.Mode), func(t *testing.T) {
+			b := test.variant.Resize(img).Bounds()
+			if b.Dx() != test.mustWidth || b.Dy() != test.mustHeight {
+				t.Errorf("%+v: size is %dx%d, must be %dx%d", test.variant, b.Dx(), b.Dy(), test.mustWidth, test.mustHeight)
+			}
+		})
+	}
+
+	//fill crops around the center, so a square keeps both halves
+	square := Variant{Mode: ResizeFill, Width: 40, Height: 40}.Resize(img)
+	b := square.Bounds()
+	left := color.RGBAModel.Convert(square.At(b.Min.X+2, b.Min.Y+20)).(color.RGBA)
+	right := color.RGBAModel.Convert(square.At(b.Max.X-3, b.Min.Y+20)).(color.RGBA)
+	if left.R < 200 || right.B < 200 {
+		t.Errorf("left is %v and right is %v, the crop must be centered", left, right)
+	}
+}
+
+func TestVariant_Encode(t *testing.T) {
+	img := gradient(40, 30, false)
+	for _, format := range []Format{FormatJPEG, FormatPNG, FormatWebP} {
+		t.Run(string(format), func(t *testing.T) {
+			v := Variant{Name: "test", Format: format, Quality: 90, Mode: ResizeNone}
+			buf := &bytes.Buffer{}
+			if err := v.Encode(buf, img); err != nil {
+				t.Fatal(err)
+			}
+			decoded, decodedFormat, err := image.Decode(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decodedFormat != string(format) || "image/"+decodedFormat != v.ContentType() {
+				t.Errorf("decoded %s, must be %s", decodedFormat, v.ContentType())
+			}
+			if decoded.Bounds().Dx() != 40 || decoded.Bounds().Dy() != 30 {
+				t.Errorf("decoded size is %v, must be 40x30", decoded.Bounds())
+			}
+			//png and webp are lossless
+			if format != FormatJPEG && PixelHash(decoded) != PixelHash(img) {
+				t.Error("lossless formats must keep every pixel")
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+	"github.com/temoto/robotstxt"
+)
+
+// DefaultUserAgent identifies the scraper in polite mode
+const DefaultUserAgent = "sco/1.0 (+https://github.com/mahdimehrabi/sco)"
+
+// RejectRobotsTxt is the reason of the images the robots.txt of their host disallows
+const RejectRobotsTxt = "robots-txt"
+
+// robotsTimeout bounds the request of a robots.txt, its rules are cached per host
+const robotsTimeout = 5 * time.Second
+
+var ErrInvalidPoliteness = errors.New("invalid politeness")
+
+// Politeness makes a run obey the robots.txt of the search engines and image
+// hosts, wait between the search pages and identify itself
+type Politeness struct {
+	// UserAgent is sent with every request and picks the robots.txt rules to obey
+	UserAgent string
+	// Delay is the wait between two search pages of the same host
+	Delay time.Duration
+	// RandomDelay is the upper bound of a random wait added to Delay
+	RandomDelay time.Duration
+}
+
+// DefaultPoliteness waits 2 to 5 seconds between search pages
+func DefaultPoliteness() Politeness {
+	return Politeness{UserAgent: DefaultUserAgent, Delay: 2 * time.Second, RandomDelay: 3 * time.Second}
+}
+
+func (p Politeness) Validate() error {
+	if p.UserAgent == "" {
+		return fmt.Errorf("%w: user agent can't be empty", ErrInvalidPoliteness)
+	}
+	if p.Delay < 0 || p.RandomDelay < 0 {
+		return fmt.Errorf("%w: delays can't be negative", ErrInvalidPoliteness)
+	}
+	return nil
+}
+
+// apply makes c obey robots.txt, wait between requests and send the user agent
+func (p Politeness) apply(c *colly.Collector) error {
+	c.IgnoreRobotsTxt = false
+	c.UserAgent = p.UserAgent
+	return c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Delay:       p.Delay,
+		RandomDelay: p.RandomDelay,
+	})
+}
+
+// robotsRules caches the robots.txt of the image hosts, colly only checks the
+// search pages it requests
+type robotsRules struct {
+	userAgent string
+	client    *http.Client
+	mtx       *sync.Mutex
+	hosts     map[string]*robotsEntry //by scheme and host
+}
+
+// robotsEntry is the robots.txt of a host, ready is closed once it is fetched
+type robotsEntry struct {
+	ready chan struct{}
+	data  *robotstxt.RobotsData
+	err   error
+}
+
+func newRobotsRules(userAgent string) *robotsRules {
+	return &robotsRules{
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: robotsTimeout},
+		mtx:       &sync.Mutex{},
+		hosts:     make(map[string]*robotsEntry),
+	}
+}
+
+// allowed reports whether the robots.txt of the host of u lets the user agent
+// request it, the robots.txt of every host is requested once
+func (r *robotsRules) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	key := u.Scheme + "://" + u.Host
+	r.mtx.Lock()
+	entry, ok := r.hosts[key]
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		r.hosts[key] = entry
+	}
+	r.mtx.Unlock()
+
+	if !ok {
+		entry.data, entry.err = r.fetch(ctx, key+"/robots.txt")
+		if entry.err != nil {
+			//a failed request is tried again by the next image of the host
+			r.mtx.Lock()
+			delete(r.hosts, key)
+			r.mtx.Unlock()
+		}
+		close(entry.ready)
+	}
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	if entry.err != nil {
+		return false, entry.err
+	}
+	return entry.data.TestAgent(u.EscapedPath(), r.userAgent), nil
+}
+
+func (r *robotsRules) fetch(ctx context.Context, robotsURL string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	//a missing robots.txt allows everything, a failing server disallows everything
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+}
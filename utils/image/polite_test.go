@@ -0,0 +1,142 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// restrictiveRobots lets sco request everything but /private/ and disallows
+// every other user agent
+const restrictiveRobots = `User-agent: sco
+Disallow: /private/
+
+User-agent: *
+Disallow: /
+`
+
+type robotsServer struct {
+	*httptest.Server
+	mtx        sync.Mutex
+	requests   map[string]int //by path
+	userAgents []string       //of the requests other than robots.txt
+}
+
+func newRobotsServer(t *testing.T) *robotsServer {
+	s := &robotsServer{requests: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mtx.Lock()
+		s.requests[r.URL.Path]++
+		if r.URL.Path != "/robots.txt" {
+			s.userAgents = append(s.userAgents, r.UserAgent())
+		}
+		s.mtx.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte(restrictiveRobots))
+			return
+		}
+		w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *robotsServer) count(path string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.requests[path]
+}
+
+func TestPoliteness_SearchPages(t *testing.T) {
+	server := newRobotsServer(t)
+	polite := Politeness{UserAgent: DefaultUserAgent, Delay: 100 * time.Millisecond}
+	c := colly.NewCollector()
+	c.AllowURLRevisit = true
+	if err := polite.apply(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Visit(server.URL + "/private/search?q=cats"); !errors.Is(err, colly.ErrRobotsTxtBlocked) {
+		t.Errorf("error is %v, a disallowed page must fail with ErrRobotsTxtBlocked", err)
+	}
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := c.Visit(server.URL + "/search?q=cats"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//the second page waits for the delay after the first one
+	if elapsed := time.Since(start); elapsed < polite.Delay {
+		t.Errorf("2 pages took %s, must wait %s between them", elapsed, polite.Delay)
+	}
+	if n := server.count("/private/search"); n != 0 {
+		t.Errorf("disallowed page was requested %d times", n)
+	}
+	for _, ua := range server.userAgents {
+		if ua != DefaultUserAgent {
+			t.Errorf("user agent is %q, must be %q", ua, DefaultUserAgent)
+		}
+	}
+
+	other := colly.NewCollector()
+	if err := (Politeness{UserAgent: "otherbot/1.0"}).apply(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Visit(server.URL + "/search?q=cats"); !errors.Is(err, colly.ErrRobotsTxtBlocked) {
+		t.Errorf("error is %v, every page is disallowed for other user agents", err)
+	}
+}
+
+func TestDownloadResizer_FetchObeysRobots(t *testing.T) {
+	server := newRobotsServer(t)
+	polite := DefaultPoliteness()
+	d := NewDownloadResizer(Config{Polite: &polite}, nil)
+	d.ctx = context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := d.fetch(server.URL + "/private/a.jpg")
+		var rejected *RejectedError
+		if !errors.As(err, &rejected) || rejected.Reason != RejectRobotsTxt {
+			t.Fatalf("error is %v, a disallowed image must be rejected with %s", err, RejectRobotsTxt)
+		}
+	}
+	if _, err := d.fetch(server.URL + "/public/a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.count("/private/a.jpg"); n != 0 {
+		t.Errorf("disallowed image was requested %d times", n)
+	}
+	if n := server.count("/robots.txt"); n != 1 {
+		t.Errorf("robots.txt was requested %d times, must be cached after the first", n)
+	}
+	if len(server.userAgents) != 1 || server.userAgents[0] != DefaultUserAgent {
+		t.Errorf("user agents are %q, must be %q", server.userAgents, DefaultUserAgent)
+	}
+
+	//without polite mode robots.txt is not requested
+	d = NewDownloadResizer(Config{}, nil)
+	d.ctx = context.Background()
+	if _, err := d.fetch(server.URL + "/private/b.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.count("/robots.txt"); n != 1 {
+		t.Errorf("robots.txt was requested %d times without polite mode", n)
+	}
+}
+
+func TestPoliteness_Validate(t *testing.T) {
+	for _, p := range []Politeness{{}, {UserAgent: "sco", Delay: -time.Second}, {UserAgent: "sco", RandomDelay: -time.Second}} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPoliteness) {
+			t.Errorf("%+v: error is %v, must be ErrInvalidPoliteness", p, err)
+		}
+	}
+	if err := DefaultPoliteness().Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,106 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly"
+)
+
+var (
+	ErrProviderExists  = errors.New("search provider already registered")
+	ErrUnknownProvider = errors.New("unknown search provider")
+)
+
+// SearchProvider builds the result pages of an image search engine and
+// extracts image urls from them
+type SearchProvider interface {
+	// Name is the unique lower case name used to turn the provider on or off
+	Name() string
+	// BuildURL returns the url of the zero based result page of query
+	BuildURL(query string, page int) string
+	// Selector is the css selector of the elements passed to Extract
+	Selector() string
+	// Extract returns the image urls found in a result element
+	Extract(*colly.HTMLElement) []string
+}
+
+var (
+	providersMtx sync.RWMutex
+	providers    = make(map[string]SearchProvider)
+)
+
+// RegisterProvider makes a provider available to every DownloadResizer
+func RegisterProvider(p SearchProvider) error {
+	providersMtx.Lock()
+	defer providersMtx.Unlock()
+	name := strings.ToLower(p.Name())
+	if _, ok := providers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrProviderExists, name)
+	}
+	providers[name] = p
+	return nil
+}
+
+func mustRegisterProvider(p SearchProvider) {
+	if err := RegisterProvider(p); err != nil {
+		panic(err)
+	}
+}
+
+// ProviderNames returns the sorted names of the registered providers
+func ProviderNames() []string {
+	providersMtx.RLock()
+	defer providersMtx.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveProviders returns the registered providers named in enabled, every
+// provider when enabled is empty, minus the ones named in disabled
+func ResolveProviders(enabled, disabled []string) ([]SearchProvider, error) {
+	providersMtx.RLock()
+	defer providersMtx.RUnlock()
+	if len(enabled) == 0 {
+		for name := range providers {
+			enabled = append(enabled, name)
+		}
+	}
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := providers[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+		}
+		skip[name] = true
+	}
+
+	resolved := make([]SearchProvider, 0, len(enabled))
+	added := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		name = strings.ToLower(strings.TrimSpace(name))
+		p, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+		}
+		if skip[name] || added[name] {
+			continue
+		}
+		added[name] = true
+		resolved = append(resolved, p)
+	}
+	if len(resolved) == 0 {
+		return nil, errors.New("no search provider enabled")
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Name() < resolved[j].Name()
+	})
+	return resolved, nil
+}
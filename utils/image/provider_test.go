@@ -0,0 +1,146 @@
+package image
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gocolly/colly"
+)
+
+type testProvider struct {
+	name string
+}
+
+func (p testProvider) Name() string                           { return p.name }
+func (p testProvider) BuildURL(query string, page int) string { return "" }
+func (p testProvider) Selector() string                       { return "img" }
+func (p testProvider) Extract(*colly.HTMLElement) []string    { return nil }
+
+func TestRegisterProvider(t *testing.T) {
+	t.Cleanup(func() {
+		providersMtx.Lock()
+		defer providersMtx.Unlock()
+		delete(providers, "testengine")
+	})
+	if err := RegisterProvider(testProvider{name: "TestEngine"}); err != nil {
+		t.Fatal(err)
+	}
+	//names are case folded, so another case is the same provider
+	if err := RegisterProvider(testProvider{name: "testengine"}); !errors.Is(err, ErrProviderExists) {
+		t.Errorf("error is %v, must be ErrProviderExists", err)
+	}
+	if err := RegisterProvider(bingProvider{}); !errors.Is(err, ErrProviderExists) {
+		t.Errorf("error is %v, a built-in provider must not be replaced", err)
+	}
+	resolved, err := ResolveProviders([]string{"TESTENGINE"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 1 || resolved[0].Name() != "TestEngine" {
+		t.Errorf("resolved %v, must resolve the registered provider", resolved)
+	}
+}
+
+func TestResolveProviders(t *testing.T) {
+	var tests = []struct {
+		name      string
+		enabled   []string
+		disabled  []string
+		mustNames []string
+		mustErr   bool
+	}{
+		{
+			name:      "every provider by default",
+			mustNames: []string{"bing", "duckduckgo", "google", "unsplash", "yandex"},
+		},
+		{
+			name:      "enabled only, sorted and without repeats",
+			enabled:   []string{"yandex", " Bing ", "bing"},
+			mustNames: []string{"bing", "yandex"},
+		},
+		{
+			name:      "disabled are skipped",
+			disabled:  []string{"google", "YANDEX"},
+			mustNames: []string{"bing", "duckduckgo", "unsplash"},
+		},
+		{
+			name:      "enabled and disabled",
+			enabled:   []string{"bing", "google"},
+			disabled:  []string{"google"},
+			mustNames: []string{"bing"},
+		},
+		{
+			name:    "unknown enabled",
+			enabled: []string{"altavista"},
+			mustErr: true,
+		},
+		{
+			name:     "unknown disabled",
+			disabled: []string{"altavista"},
+			mustErr:  true,
+		},
+		{
+			name:     "everything disabled",
+			enabled:  []string{"bing"},
+			disabled: []string{"bing"},
+			mustErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolved, err := ResolveProviders(test.enabled, test.disabled)
+			if (err != nil) != test.mustErr {
+				t.Fatalf("error is %v, must fail:%t", err, test.mustErr)
+			}
+			if strings.Contains(test.name, "unknown") && !errors.Is(err, ErrUnknownProvider) {
+				t.Errorf("error is %v, must be ErrUnknownProvider", err)
+			}
+			names := providerNamesOf(resolved)
+			if strings.Join(names, ",") != strings.Join(test.mustNames, ",") {
+				t.Errorf("resolved %v, must resolve %v", names, test.mustNames)
+			}
+		})
+	}
+}
+
+func providerNamesOf(providers []SearchProvider) []string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+func TestProviders_BuildURL(t *testing.T) {
+	var tests = []struct {
+		provider  SearchProvider
+		page      int
+		mustParam string
+		mustValue string
+	}{
+		{provider: googleProvider{}, page: 0, mustParam: "start", mustValue: "0"},
+		{provider: googleProvider{}, page: 2, mustParam: "start", mustValue: "200"},
+		{provider: googleProvider{}, page: 2, mustParam: "ijn", mustValue: "2"},
+		{provider: bingProvider{}, page: 0, mustParam: "first", mustValue: "1"},
+		{provider: bingProvider{}, page: 2, mustParam: "first", mustValue: "71"},
+		{provider: duckDuckGoProvider{}, page: 0, mustParam: "s", mustValue: "0"},
+		{provider: duckDuckGoProvider{}, page: 2, mustParam: "s", mustValue: "200"},
+		{provider: yandexProvider{}, page: 2, mustParam: "p", mustValue: "2"},
+		{provider: unsplashProvider{}, page: 0, mustParam: "page", mustValue: "1"},
+		{provider: unsplashProvider{}, page: 2, mustParam: "page", mustValue: "3"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.provider.BuildURL("red cars", test.page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value := u.Query().Get(test.mustParam); value != test.mustValue {
+			t.Errorf("%s page %d: %s is %q, must be %q", test.provider.Name(), test.page, test.mustParam, value, test.mustValue)
+		}
+		if !strings.Contains(u.String(), "red") || !strings.Contains(u.String(), "cars") {
+			t.Errorf("%s: %s doesn't hold the query", test.provider.Name(), u)
+		}
+	}
+}
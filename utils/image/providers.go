@@ -0,0 +1,155 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gocolly/colly"
+)
+
+// result page sizes used to compute the offset of a page
+const (
+	googlePageSize     = 100
+	bingPageSize       = 35
+	duckDuckGoPageSize = 100
+)
+
+func init() {
+	mustRegisterProvider(googleProvider{})
+	mustRegisterProvider(bingProvider{})
+	mustRegisterProvider(duckDuckGoProvider{})
+	mustRegisterProvider(yandexProvider{})
+	mustRegisterProvider(unsplashProvider{})
+}
+
+type googleProvider struct{}
+
+func (googleProvider) Name() string { return "google" }
+
+func (googleProvider) BuildURL(query string, page int) string {
+	//ijn is the zero based page of the image results, start the offset of the first result
+	return fmt.Sprintf("https://www.google.com/search?tbm=isch&q=%s&ijn=%d&start=%d",
+		url.QueryEscape(query), page, page*googlePageSize)
+}
+
+func (googleProvider) Selector() string { return "img" }
+
+// Extract follows the lazy load attributes to the real image and falls back to the
+// base64 thumbnail in src
+func (googleProvider) Extract(e *colly.HTMLElement) []string {
+	src := lazyImageURL(e.Attr)
+	if src != "" && !isDataURI(src) {
+		src = e.Request.AbsoluteURL(src)
+	}
+	return nonEmpty(src)
+}
+
+type bingProvider struct{}
+
+func (bingProvider) Name() string { return "bing" }
+
+func (bingProvider) BuildURL(query string, page int) string {
+	//first is the one based offset of the first result
+	return fmt.Sprintf("https://www.bing.com/images/search?q=%s&first=%d&count=%d",
+		url.QueryEscape(query), page*bingPageSize+1, bingPageSize)
+}
+
+func (bingProvider) Selector() string { return "a.iusc" }
+
+func (bingProvider) Extract(e *colly.HTMLElement) []string {
+	return nonEmpty(extractImageURLFromBing(e))
+}
+
+func extractImageURLFromBing(e *colly.HTMLElement) string {
+	data := e.Attr("m")
+	start := strings.Index(data, `"murl":"`)
+	if start == -1 {
+		return ""
+	}
+	start += len(`"murl":"`)
+	end := strings.Index(data[start:], `"`)
+	if end == -1 {
+		return ""
+	}
+	return data[start : start+end]
+}
+
+type duckDuckGoProvider struct{}
+
+func (duckDuckGoProvider) Name() string { return "duckduckgo" }
+
+func (duckDuckGoProvider) BuildURL(query string, page int) string {
+	//s is the offset of the first result
+	return fmt.Sprintf("https://duckduckgo.com/?q=%s&iax=images&ia=images&s=%d",
+		url.QueryEscape(query), page*duckDuckGoPageSize)
+}
+
+func (duckDuckGoProvider) Selector() string { return "img.tile--img__img" }
+
+// Extract returns the original image behind the duckduckgo image proxy when it can
+func (duckDuckGoProvider) Extract(e *colly.HTMLElement) []string {
+	src := lazyImageURL(e.Attr)
+	if isDataURI(src) {
+		return []string{src}
+	}
+	src = e.Request.AbsoluteURL(src)
+	if proxied, err := url.Parse(src); err == nil {
+		if original := proxied.Query().Get("u"); original != "" {
+			return []string{original}
+		}
+	}
+	return nonEmpty(src)
+}
+
+type yandexProvider struct{}
+
+func (yandexProvider) Name() string { return "yandex" }
+
+func (yandexProvider) BuildURL(query string, page int) string {
+	//p is the zero based page
+	return fmt.Sprintf("https://yandex.com/images/search?text=%s&p=%d", url.QueryEscape(query), page)
+}
+
+func (yandexProvider) Selector() string { return "div.serp-item" }
+
+// Extract reads the original image url from the data-bem json of a result item
+func (yandexProvider) Extract(e *colly.HTMLElement) []string {
+	var bem struct {
+		SerpItem struct {
+			ImgHref string `json:"img_href"`
+		} `json:"serp-item"`
+	}
+	if err := json.Unmarshal([]byte(e.Attr("data-bem")), &bem); err == nil && bem.SerpItem.ImgHref != "" {
+		return []string{bem.SerpItem.ImgHref}
+	}
+	return nonEmpty(e.Request.AbsoluteURL(e.ChildAttr("img", "src")))
+}
+
+type unsplashProvider struct{}
+
+func (unsplashProvider) Name() string { return "unsplash" }
+
+func (unsplashProvider) BuildURL(query string, page int) string {
+	//page is one based
+	return fmt.Sprintf("https://unsplash.com/s/photos/%s?page=%d", url.PathEscape(query), page+1)
+}
+
+func (unsplashProvider) Selector() string { return "img[srcset]" }
+
+// Extract keeps only photos served by the unsplash image cdn, avatars and logos are skipped
+func (unsplashProvider) Extract(e *colly.HTMLElement) []string {
+	src := e.Attr("src")
+	if !strings.HasPrefix(src, "https://images.unsplash.com/photo-") {
+		return nil
+	}
+	return []string{src}
+}
+
+func nonEmpty(u string) []string {
+	if u == "" {
+		return nil
+	}
+	return []string{u}
+}
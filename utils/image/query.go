@@ -0,0 +1,142 @@
+package image
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoQueries        = errors.New("no search queries")
+	ErrQueriesExhausted = errors.New("every query ran out of new results")
+)
+
+var petQueries = []string{
+	"cute kittens", "puppies", "hamsters", "bunnies", "goldfish",
+	"parrots", "turtles", "guinea pigs", "hedgehogs", "ferrets",
+	"pet snakes", "pet lizards", "pet frogs", "pet spiders", "pet mice",
+	"pet rats", "pet birds", "pet rabbits", "pet ducks", "pet chickens",
+}
+
+// Query is a search term, queries with a higher weight are picked more often
+type Query struct {
+	Text   string
+	Weight int
+}
+
+// QueryList picks random queries proportionally to their weights
+type QueryList struct {
+	queries    []Query
+	cumulative []int
+}
+
+// NewQueryList skips empty queries and merges the repeated ones into a single
+// query with the sum of their weights, so every query text is scraped once
+func NewQueryList(queries []Query) (*QueryList, error) {
+	ql := &QueryList{}
+	index := make(map[string]int, len(queries))
+	for _, q := range queries {
+		q.Text = strings.TrimSpace(q.Text)
+		if q.Text == "" {
+			continue
+		}
+		if q.Weight < 1 {
+			return nil, fmt.Errorf("query %q: weight must be positive", q.Text)
+		}
+		if i, ok := index[q.Text]; ok {
+			ql.queries[i].Weight += q.Weight
+			continue
+		}
+		index[q.Text] = len(ql.queries)
+		ql.queries = append(ql.queries, q)
+	}
+	if len(ql.queries) == 0 {
+		return nil, ErrNoQueries
+	}
+	total := 0
+	for _, q := range ql.queries {
+		total += q.Weight
+		ql.cumulative = append(ql.cumulative, total)
+	}
+	return ql, nil
+}
+
+// DefaultQueryList returns the built-in pet queries with equal weights
+func DefaultQueryList() *QueryList {
+	queries := make([]Query, 0, len(petQueries))
+	for _, q := range petQueries {
+		queries = append(queries, Query{Text: q, Weight: 1})
+	}
+	ql, _ := NewQueryList(queries)
+	return ql
+}
+
+func (ql *QueryList) Pick(r *rand.Rand) string {
+	n := r.Intn(ql.cumulative[len(ql.cumulative)-1])
+	i := sort.SearchInts(ql.cumulative, n+1)
+	return ql.queries[i].Text
+}
+
+func (ql *QueryList) Queries() []Query {
+	return ql.queries
+}
+
+// ParseQueries reads one query per line, a line may end with ";<weight>" to
+// give the query a weight (default 1). Empty lines and lines starting with #
+// are ignored
+func ParseQueries(r io.Reader) ([]Query, error) {
+	queries := make([]Query, 0)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		q, err := ParseQuery(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		queries = append(queries, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// ParseQuery parses a single "<query>[;<weight>]" entry
+func ParseQuery(text string) (Query, error) {
+	q := Query{Text: strings.TrimSpace(text), Weight: 1}
+	if i := strings.LastIndex(q.Text, ";"); i != -1 {
+		weight, err := strconv.Atoi(strings.TrimSpace(q.Text[i+1:]))
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid weight in %q: %w", text, err)
+		}
+		q.Text = strings.TrimSpace(q.Text[:i])
+		q.Weight = weight
+	}
+	if q.Text == "" {
+		return Query{}, fmt.Errorf("empty query in %q", text)
+	}
+	if q.Weight < 1 {
+		return Query{}, fmt.Errorf("weight of %q must be positive", q.Text)
+	}
+	return q, nil
+}
+
+func LoadQueries(path string) ([]Query, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseQueries(f)
+}
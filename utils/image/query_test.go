@@ -0,0 +1,129 @@
+package image
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	var tests = []struct {
+		text      string
+		mustQuery Query
+		mustErr   bool
+	}{
+		{text: "red cars", mustQuery: Query{Text: "red cars", Weight: 1}},
+		{text: " red cars ; 3 ", mustQuery: Query{Text: "red cars", Weight: 3}},
+		{text: "a;b;2", mustQuery: Query{Text: "a;b", Weight: 2}},
+		{text: "red cars;x", mustErr: true},
+		{text: "red cars;0", mustErr: true},
+		{text: "red cars;-2", mustErr: true},
+		{text: ";3", mustErr: true},
+		{text: "  ", mustErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			q, err := ParseQuery(test.text)
+			if (err != nil) != test.mustErr {
+				t.Fatalf("error is %v, must fail:%t", err, test.mustErr)
+			}
+			if q != test.mustQuery {
+				t.Errorf("query is %+v, must be %+v", q, test.mustQuery)
+			}
+		})
+	}
+}
+
+func TestParseQueries(t *testing.T) {
+	queries, err := ParseQueries(strings.NewReader("# pets\ncats;2\n\n  \ndogs\n#birds;3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	must := []Query{{Text: "cats", Weight: 2}, {Text: "dogs", Weight: 1}}
+	if len(queries) != len(must) {
+		t.Fatalf("queries are %+v, must be %+v", queries, must)
+	}
+	for i := range must {
+		if queries[i] != must[i] {
+			t.Errorf("queries are %+v, must be %+v", queries, must)
+		}
+	}
+
+	if _, err = ParseQueries(strings.NewReader("cats\ndogs;0\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error is %v, must name line 2", err)
+	}
+}
+
+func TestNewQueryList(t *testing.T) {
+	var tests = []struct {
+		name        string
+		queries     []Query
+		mustQueries []Query
+		mustErr     error
+	}{
+		{
+			name:        "duplicates are merged with the sum of their weights",
+			queries:     []Query{{Text: "cats", Weight: 1}, {Text: "dogs", Weight: 2}, {Text: " cats ", Weight: 3}},
+			mustQueries: []Query{{Text: "cats", Weight: 4}, {Text: "dogs", Weight: 2}},
+		},
+		{
+			name:        "empty queries are skipped",
+			queries:     []Query{{Text: " ", Weight: 1}, {Text: "cats", Weight: 1}},
+			mustQueries: []Query{{Text: "cats", Weight: 1}},
+		},
+		{
+			name:    "zero weight",
+			queries: []Query{{Text: "cats"}},
+			mustErr: errors.New("weight must be positive"),
+		},
+		{
+			name:    "no queries",
+			queries: []Query{{Text: "", Weight: 1}},
+			mustErr: ErrNoQueries,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ql, err := NewQueryList(test.queries)
+			if test.mustErr != nil {
+				if err == nil || !strings.Contains(err.Error(), test.mustErr.Error()) {
+					t.Fatalf("error is %v, must be %v", err, test.mustErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			queries := ql.Queries()
+			if len(queries) != len(test.mustQueries) {
+				t.Fatalf("queries are %+v, must be %+v", queries, test.mustQueries)
+			}
+			for i := range queries {
+				if queries[i] != test.mustQueries[i] {
+					t.Errorf("queries are %+v, must be %+v", queries, test.mustQueries)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryList_Pick(t *testing.T) {
+	ql, err := NewQueryList([]Query{{Text: "cats", Weight: 3}, {Text: "dogs", Weight: 1}, {Text: "cats", Weight: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	picks := make(map[string]int)
+	const n = 10000
+	for i := 0; i < n; i++ {
+		picks[ql.Pick(r)]++
+	}
+	if len(picks) != 2 {
+		t.Fatalf("picked %v, must pick only cats and dogs", picks)
+	}
+	//cats weighs 4 of 5
+	if ratio := float64(picks["cats"]) / n; ratio < 0.77 || ratio > 0.83 {
+		t.Errorf("cats was picked %.2f of the time, must be about 0.8", ratio)
+	}
+}
@@ -0,0 +1,68 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stats are the counters of a download run
+type Stats struct {
+	Saved      uint64
+	Duplicates uint64
+	Failed     uint64            //network, decoding and storage errors
+	Rejected   map[string]uint64 //by reason
+	Engines    []EngineHealth
+}
+
+func (s Stats) RejectedTotal() uint64 {
+	var total uint64
+	for _, n := range s.Rejected {
+		total += n
+	}
+	return total
+}
+
+func (s Stats) String() string {
+	reasons := make([]string, 0, len(s.Rejected))
+	for reason := range s.Rejected {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%s: %d", reason, s.Rejected[reason])
+	}
+	engines := make([]string, 0, len(s.Engines))
+	for _, e := range s.Engines {
+		engines = append(engines, e.String())
+	}
+	return fmt.Sprintf("saved %d, duplicates %d, rejected %d (%s), failed %d, engines: %s",
+		s.Saved, s.Duplicates, s.RejectedTotal(), strings.Join(reasons, ", "), s.Failed, strings.Join(engines, "; "))
+}
+
+type runStats struct {
+	mtx   sync.Mutex
+	stats Stats
+}
+
+func newRunStats() *runStats {
+	return &runStats{stats: Stats{Rejected: make(map[string]uint64)}}
+}
+
+func (r *runStats) update(f func(*Stats)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	f(&r.stats)
+}
+
+func (r *runStats) snapshot() Stats {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	s := r.stats
+	s.Rejected = make(map[string]uint64, len(r.stats.Rejected))
+	for reason, n := range r.stats.Rejected {
+		s.Rejected[reason] = n
+	}
+	return s
+}